@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/scheduler"
+)
+
+// commands are the subcommands accepted before the flags. Without one the
+// worker runs as a daemon, as "run" without --once or --dry-run does.
+var commands = map[string]bool{"run": true, "validate-config": true, "replay": true, "batch": true}
+
+// splitCommand separates a leading subcommand from the flag arguments.
+func splitCommand(args []string) (string, []string) {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return "run", args
+	}
+	if !commands[args[0]] {
+		log.Fatalf("❌ Unknown command %q (want run, batch, replay or validate-config)", args[0])
+	}
+	return args[0], args[1:]
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintln(out, "Usage:")
+	fmt.Fprintln(out, "  autopost [run] [flags]        run the scheduler (the default)")
+	fmt.Fprintln(out, "  autopost run --once [flags]   run every job once and exit non-zero if any prompt failed")
+	fmt.Fprintln(out, "  autopost run --dry-run        generate one prompt and print it instead of sending")
+	fmt.Fprintln(out, "  autopost batch --count N      generate N prompts with a worker pool and print a summary")
+	fmt.Fprintln(out, "  autopost replay               re-send payloads from the run history that failed to send")
+	fmt.Fprintln(out, "  autopost validate-config      check the environment and config file, then exit")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	flag.PrintDefaults()
+}
+
+// runReplay re-sends the history's unsent payloads once and exits
+// non-zero if any are still pending.
+func runReplay() {
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+	scheduler.WatchSignals(cancelRuns)
+
+	sent, pending := scheduler.ReplayFailed(runCtx)
+	state.FlushAll()
+	log.Printf("🔁 Replay: %d payload(s) sent, %d still pending", sent, pending)
+	if pending > 0 {
+		os.Exit(1)
+	}
+}
+
+// runBatchCommand generates count prompts for the named job, or the first
+// scheduled one, and exits non-zero if any failed.
+func runBatchCommand(jobName string, count, concurrency int) {
+	if count < 1 {
+		log.Fatal("❌ batch needs --count N")
+	}
+	if concurrency < 1 {
+		log.Fatal("❌ --concurrency must be at least 1")
+	}
+	job := scheduler.ScheduledJobs()[0]
+	if jobName != "" {
+		if job = scheduler.FindJob(jobName); job == nil {
+			log.Fatalf("❌ Unknown job %q", jobName)
+		}
+	}
+
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+	scheduler.WatchSignals(cancelRuns)
+
+	log.Printf("🚀 Batch: generating %d prompt(s) for job %q, %d at a time", count, job.Label(), concurrency)
+	sum := scheduler.Batch(generator.WithJob(runCtx, job.Job), count, concurrency)
+	state.FlushAll()
+
+	log.Printf("📊 Batch finished in %s: %d succeeded, %d failed", sum.Elapsed.Round(time.Millisecond), sum.Succeeded, sum.Failed())
+	for _, sector := range sortedKeys(sum.BySector) {
+		log.Printf("   ✅ %s: %d", sector, sum.BySector[sector])
+	}
+	for _, stage := range sortedKeys(sum.ByStage) {
+		log.Printf("   ❌ %s: %d", stage, sum.ByStage[stage])
+	}
+	if sum.Failed() > 0 || sum.Runs < count {
+		os.Exit(1)
+	}
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateConfig reports every problem loadConfig does not already treat
+// as fatal: missing credentials, a missing backend and prompt templates
+// that do not parse.
+func validateConfig() error {
+	var problems []error
+	if key := llmConfig.MissingAPIKey(); key != "" {
+		problems = append(problems, fmt.Errorf("%s not set", key))
+	}
+	if output.BackendAPI == "" && output.HasOutput(output.Outputs, "backend") {
+		problems = append(problems, errors.New("BACKEND_API_URL not set (in the environment or as backend.url in the config file)"))
+	}
+
+	if generator.PromptTemplateDir != "" {
+		paths, _ := filepath.Glob(filepath.Join(generator.PromptTemplateDir, "*.tmpl"))
+		for _, path := range paths {
+			text, err := os.ReadFile(path)
+			if err == nil {
+				_, err = template.New(filepath.Base(path)).Parse(string(text))
+			}
+			if err != nil {
+				problems = append(problems, fmt.Errorf("template %s: %w", path, err))
+			}
+		}
+	}
+	return generator.JoinProblems(problems)
+}
+
+// runValidateConfig prints a summary of the loaded configuration, or its
+// problems, and exits non-zero if there are any.
+func runValidateConfig() {
+	if err := validateConfig(); err != nil {
+		log.Fatal("❌ Invalid configuration: ", err)
+	}
+
+	log.Printf("🤖 Provider %s, model %s", generator.LLM.Name(), generator.LLM.Model())
+	log.Println("🔗 BACKEND_API:", output.BackendAPI)
+	for _, j := range scheduler.ScheduledJobs() {
+		name := j.Name
+		if name == "" {
+			name = "default"
+		}
+		log.Printf("📅 Job %q on schedule %q, next run at %s", name, j.Schedule, j.Next(time.Now()).Format(time.RFC3339))
+	}
+	log.Println("✅ Configuration is valid")
+}
@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/approval"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/scheduler"
+	"promptcraft-groq/pkg/sources"
+)
+
+// llmConfig is the model configuration read from the environment and
+// groqAuthScheme how Groq requests are signed, kept so rotated secrets can
+// be applied to them.
+var (
+	llmConfig      = llm.DefaultConfig()
+	groqAuthScheme = "bearer"
+)
+
+// buildGroqAuth signs Groq requests for the hmac scheme; bearer needs no
+// AuthFunc since the provider sends the API key itself.
+func buildGroqAuth(scheme string) llm.AuthFunc {
+	if scheme == "hmac" {
+		return llm.HMACAuth(envString("GROQ_HMAC_KEY_ID", ""), envString("GROQ_HMAC_SECRET", ""))
+	}
+	return nil
+}
+
+// buildLLM rebuilds the providers and the image generator from llmConfig.
+func buildLLM() {
+	provider, err := llm.New(llmConfig)
+	if err != nil {
+		log.Fatalf("❌ Invalid LLM_PROVIDER: %v", err)
+	}
+	generator.LLM = provider
+	if generator.Images, err = llm.NewImageGenerator(llmConfig); err != nil {
+		log.Fatalf("❌ Invalid IMAGE_PROVIDER: %v", err)
+	}
+}
+
+// loadEnvFile loads variables from an explicit ENV_FILE / --env-file path,
+// or from ./.env when it exists. Variables already set in the environment
+// win over the file.
+func loadEnvFile(path string) {
+	if path == "" {
+		if err := godotenv.Load(); err != nil && !os.IsNotExist(err) {
+			log.Println("⚠️ Could not load .env:", err)
+		}
+		return
+	}
+	if err := godotenv.Load(path); err != nil {
+		log.Fatalf("❌ Could not load env file %s: %v", path, err)
+	}
+	log.Println("📄 Loaded environment from", path)
+}
+
+func loadConfig() {
+	LogFormat = strings.ToLower(envString("LOG_FORMAT", LogFormat))
+	LogLevel = strings.ToLower(envString("LOG_LEVEL", LogLevel))
+	if !envTemplateOnly {
+		setupLogging()
+	}
+	configureSecrets()
+
+	llmConfig.GroqAPIKey = envString("GROQ_API_KEY", "")
+	output.BackendAPI = envString("BACKEND_API_URL", "")
+	// DRY_RUN=true is the same as --dry-run.
+	generator.DryRun = envBool("DRY_RUN", false) || generator.DryRun
+
+	scheduler.RecentRuns = scheduler.NewRunRing(envInt("RECENT_RUNS_SIZE", 20))
+
+	generator.TagMin = envInt("TAG_MIN", generator.TagMin)
+	generator.TagMax = envInt("TAG_MAX", generator.TagMax)
+	generator.UseCaseMin = envInt("USECASE_MIN", generator.UseCaseMin)
+	generator.UseCaseMax = envInt("USECASE_MAX", generator.UseCaseMax)
+	if generator.TagMin < 0 || generator.TagMin > generator.TagMax {
+		log.Fatalf("❌ Invalid tag range TAG_MIN=%d TAG_MAX=%d", generator.TagMin, generator.TagMax)
+	}
+	if generator.UseCaseMin < 0 || generator.UseCaseMin > generator.UseCaseMax {
+		log.Fatalf("❌ Invalid use case range USECASE_MIN=%d USECASE_MAX=%d", generator.UseCaseMin, generator.UseCaseMax)
+	}
+
+	groqAuthScheme = envString("GROQ_AUTH_SCHEME", groqAuthScheme)
+	switch groqAuthScheme {
+	case "bearer":
+	case "hmac":
+		if envString("GROQ_HMAC_SECRET", "") == "" {
+			log.Fatal("❌ GROQ_AUTH_SCHEME=hmac requires GROQ_HMAC_SECRET")
+		}
+	default:
+		log.Fatalf("❌ Unknown GROQ_AUTH_SCHEME %q (want bearer or hmac)", groqAuthScheme)
+	}
+	llmConfig.GroqAuth = buildGroqAuth(groqAuthScheme)
+
+	llmConfig.Provider = strings.ToLower(envString("LLM_PROVIDER", llmConfig.Provider))
+	llmConfig.OpenAIAPIKey = envString("OPENAI_API_KEY", "")
+	llmConfig.AnthropicAPIKey = envString("ANTHROPIC_API_KEY", "")
+	llmConfig.GeminiAPIKey = envString("GEMINI_API_KEY", "")
+	llmConfig.Endpoint = envString("LLM_ENDPOINT", "")
+	llmConfig.Model = envString("LLM_MODEL", "")
+	llmConfig.Stream = envBool("LLM_STREAM", llmConfig.Stream)
+
+	llmConfig.Image.Provider = strings.ToLower(envString("IMAGE_PROVIDER", ""))
+	switch llmConfig.Image.Provider {
+	case "":
+	case "openai":
+		llmConfig.Image.APIKey = envString("IMAGE_API_KEY", llmConfig.OpenAIAPIKey)
+	case "stability":
+		llmConfig.Image.APIKey = envString("IMAGE_API_KEY", envString("STABILITY_API_KEY", ""))
+	default:
+		log.Fatalf("❌ Unknown IMAGE_PROVIDER %q (want openai or stability)", llmConfig.Image.Provider)
+	}
+	if llmConfig.Image.Provider != "" && llmConfig.Image.APIKey == "" {
+		log.Fatalf("❌ IMAGE_PROVIDER=%s needs IMAGE_API_KEY", llmConfig.Image.Provider)
+	}
+	llmConfig.Image.Model = envString("IMAGE_MODEL", "")
+	llmConfig.Image.Size = envString("IMAGE_SIZE", llmConfig.Image.Size)
+	llmConfig.Image.Endpoint = envString("IMAGE_ENDPOINT", "")
+	generator.ImageStyle = envString("IMAGE_STYLE", "")
+	generator.ImageRequired = envBool("IMAGE_REQUIRED", generator.ImageRequired)
+	generator.ImageField = envString("IMAGE_FIELD", generator.ImageField)
+
+	output.ImageStorage = strings.ToLower(envString("IMAGE_STORAGE", output.ImageStorage))
+	switch output.ImageStorage {
+	case "backend", "s3", "gcs":
+	default:
+		log.Fatalf("❌ Unknown IMAGE_STORAGE %q (want backend, s3 or gcs)", output.ImageStorage)
+	}
+	output.BackendUploadURL = envString("BACKEND_UPLOAD_URL", "")
+	output.BackendUploadURLField = envString("BACKEND_UPLOAD_URL_FIELD", output.BackendUploadURLField)
+	output.S3Bucket = envString("S3_BUCKET", "")
+	output.S3Region = envString("S3_REGION", envString("AWS_REGION", output.S3Region))
+	output.S3Endpoint = envString("S3_ENDPOINT", "")
+	output.S3AccessKeyID = envString("AWS_ACCESS_KEY_ID", "")
+	output.S3SecretAccessKey = envString("AWS_SECRET_ACCESS_KEY", "")
+	output.S3SessionToken = envString("AWS_SESSION_TOKEN", "")
+	output.GCSBucket = envString("GCS_BUCKET", "")
+	output.GCSAccessToken = envString("GCS_ACCESS_TOKEN", "")
+	if path := envString("GCS_CREDENTIALS_FILE", envString("GOOGLE_APPLICATION_CREDENTIALS", "")); path != "" && output.ImageStorage == "gcs" {
+		sa, err := output.LoadServiceAccount(path)
+		if err != nil {
+			log.Fatalf("❌ Invalid GCS_CREDENTIALS_FILE %s: %v", path, err)
+		}
+		output.GCSCredentials = sa
+	}
+	output.ImagePublicURL = envString("IMAGE_PUBLIC_URL", "")
+
+	budget := envInt("TOKEN_BUDGET_DAILY", 0)
+	if budget < 0 {
+		log.Fatalf("❌ TOKEN_BUDGET_DAILY must not be negative, got %d", budget)
+	}
+	llm.Usage = llm.LoadUsageLedger(envString("USAGE_FILE", "token_usage.json"), budget)
+
+	generator.TagFallback = envString("TAG_FALLBACK", generator.TagFallback)
+	if generator.TagFallback != "fail" && generator.TagFallback != "derive" {
+		log.Fatalf("❌ Unknown TAG_FALLBACK %q (want fail or derive)", generator.TagFallback)
+	}
+
+	if spec := envString("TAG_CANONICAL", ""); spec != "" {
+		canonical, err := generator.ParseTagCanonical(spec)
+		if err != nil {
+			log.Fatal("❌ Invalid TAG_CANONICAL: ", err)
+		}
+		generator.TagCanonical = canonical
+	}
+
+	// USE_TOOL_CALLING=true is the older spelling of STRUCTURED_OUTPUT=tools.
+	defaultStructured := generator.StructuredOutput
+	if envBool("USE_TOOL_CALLING", false) {
+		defaultStructured = "tools"
+	}
+	generator.StructuredOutput = strings.ToLower(envString("STRUCTURED_OUTPUT", defaultStructured))
+	switch generator.StructuredOutput {
+	case "off", "json", "tools":
+	default:
+		log.Fatalf("❌ Invalid STRUCTURED_OUTPUT %q (want off, json or tools)", generator.StructuredOutput)
+	}
+	generator.JSONReaskAttempts = envInt("JSON_REASK_ATTEMPTS", generator.JSONReaskAttempts)
+
+	scheduler.PromptsPerRun = envInt("PROMPTS_PER_RUN", scheduler.PromptsPerRun)
+	scheduler.MaxPerRun = envInt("MAX_PER_RUN", scheduler.MaxPerRun)
+	if scheduler.PromptsPerRun < 1 || scheduler.MaxPerRun < 1 {
+		log.Fatal("❌ PROMPTS_PER_RUN and MAX_PER_RUN must be at least 1")
+	}
+	scheduler.MaxConcurrency = envInt("MAX_CONCURRENCY", scheduler.MaxConcurrency)
+	if scheduler.MaxConcurrency < 1 {
+		log.Fatal("❌ MAX_CONCURRENCY must be at least 1")
+	}
+
+	state.FlushInterval = envDuration("STATE_FLUSH_INTERVAL", state.FlushInterval)
+	generator.DedupWindow = envInt("DEDUP_WINDOW", generator.DedupWindow)
+	if generator.DedupWindow < 1 {
+		log.Fatal("❌ DEDUP_WINDOW must be at least 1")
+	}
+	generator.DedupThreshold = envFloat("DEDUP_THRESHOLD", generator.DedupThreshold)
+	if generator.DedupThreshold <= 0 || generator.DedupThreshold > 1 {
+		log.Fatalf("❌ DEDUP_THRESHOLD must be in (0, 1], got %g", generator.DedupThreshold)
+	}
+	generator.DedupHashHistory = envInt("DEDUP_HASH_HISTORY", generator.DedupHashHistory)
+	if generator.DedupHashHistory < generator.DedupWindow {
+		generator.DedupHashHistory = generator.DedupWindow
+	}
+	generator.Seen = generator.LoadSeenStore(envString("DEDUP_FILE", "seen.json"), generator.DedupWindow)
+	generator.DedupRegenAttempts = envInt("DEDUP_REGEN_ATTEMPTS", generator.DedupRegenAttempts)
+	if generator.DedupRegenAttempts < 0 {
+		generator.DedupRegenAttempts = 0
+	}
+
+	generator.OTLPEndpoint = envString("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+	generator.OTELService = envString("OTEL_SERVICE_NAME", generator.OTELService)
+	if generator.OTLPEndpoint != "" {
+		log.Println("🔭 Exporting traces to", generator.OTLPEndpoint)
+	}
+
+	output.BackendDefaultCooldown = envDuration("BACKEND_429_COOLDOWN", output.BackendDefaultCooldown)
+
+	if spec := envString("SECTORS", ""); spec != "" {
+		if generator.Sectors = generator.ParseSectors(spec); len(generator.Sectors) == 0 {
+			log.Fatal("❌ SECTORS lists no sectors")
+		}
+	}
+	generator.SectorOrder = envString("SECTOR_ORDER", generator.SectorOrder)
+	if generator.SectorOrder != "random" && generator.SectorOrder != "round-robin" {
+		log.Fatalf("❌ Unknown SECTOR_ORDER %q (want random or round-robin)", generator.SectorOrder)
+	}
+	if spec := envString("SECTOR_WEIGHTS", ""); spec != "" {
+		weights, err := generator.ParseSectorWeights(spec)
+		if err != nil {
+			log.Fatal("❌ Invalid SECTOR_WEIGHTS: ", err)
+		}
+		generator.SectorWeights = weights
+	}
+	if seed := envString("SECTOR_SEED", ""); seed != "" {
+		n, err := strconv.ParseInt(seed, 10, 64)
+		if err != nil {
+			log.Fatal("❌ Invalid SECTOR_SEED: ", err)
+		}
+		generator.SeedSectorRand(n)
+	}
+
+	if path := envString("OUTPUT_SCHEMA_PATH", ""); path != "" {
+		schema, err := generator.LoadOutputSchema(path)
+		if err != nil {
+			log.Fatal("❌ Invalid OUTPUT_SCHEMA_PATH: ", err)
+		}
+		generator.OutputSchema = schema
+		log.Println("📐 Using output schema from", path)
+	}
+
+	contentTypes, err := generator.LoadContentTypes(envString("CONTENT_TYPES_DIR", "content_types"))
+	if err != nil {
+		log.Fatal("❌ Invalid content type: ", err)
+	}
+	generator.ContentTypes = contentTypes
+	if name := envString("CONTENT_TYPE", generator.PromptContentType); name != generator.PromptContentType {
+		if generator.OutputSchema != nil {
+			log.Fatal("❌ Set either OUTPUT_SCHEMA_PATH or CONTENT_TYPE, not both")
+		}
+		if generator.DefaultContentType, err = generator.FindContentType(name); err != nil {
+			log.Fatal("❌ Invalid CONTENT_TYPE: ", err)
+		}
+		log.Println("📐 Generating content type", name)
+	}
+
+	if spec := envString("SECTOR_TARGETS", ""); spec != "" {
+		targets, err := generator.ParseSectorTargets(spec)
+		if err != nil {
+			log.Fatal("❌ Invalid SECTOR_TARGETS: ", err)
+		}
+		generator.SectorTargets = targets
+	}
+	generator.SectorCounts = generator.LoadSectorCounter(envString("SECTOR_COUNTS_FILE", "sector_counts.json"))
+
+	scheduler.RunHistoryFile = envString("RUN_HISTORY_FILE", scheduler.RunHistoryFile)
+	scheduler.RunHistoryMaxAge = envDuration("RUN_HISTORY_MAX_AGE", scheduler.RunHistoryMaxAge)
+	scheduler.ReplayInterval = envDuration("REPLAY_INTERVAL", scheduler.ReplayInterval)
+	scheduler.History = scheduler.LoadRunHistory(scheduler.RunHistoryFile)
+
+	generator.AvoidRecentTitles = envInt("AVOID_RECENT_TITLES", generator.AvoidRecentTitles)
+	generator.Tone = strings.ToLower(envString("TONE", ""))
+	if generator.Tone != "" && !generator.ValidTone(generator.Tone) {
+		log.Fatalf("❌ Unknown TONE %q (want one of %s)", generator.Tone, strings.Join(generator.AllowedTones, ", "))
+	}
+	generator.PromptTemplateDir = envString("PROMPT_TEMPLATE_DIR", generator.PromptTemplateDir)
+
+	generator.TimestampField = envString("TIMESTAMP_FIELD", generator.TimestampField)
+
+	if v := envString("LLM_TEMPERATURE", ""); v != "" {
+		t, err := strconv.ParseFloat(v, 64)
+		if err != nil || t < 0 || t > 2 {
+			log.Fatalf("❌ Invalid LLM_TEMPERATURE %q (want a number from 0 to 2)", v)
+		}
+		llmConfig.Temperature = &t
+	}
+
+	if scheduler.Timezone = envString("CRON_TZ", ""); scheduler.Timezone != "" {
+		if _, err := time.LoadLocation(scheduler.Timezone); err != nil {
+			log.Fatalf("❌ Invalid CRON_TZ %q: %v", scheduler.Timezone, err)
+		}
+	}
+	scheduler.CronSchedule = envString("CRON_SCHEDULE", scheduler.CronSchedule)
+	schedule, err := scheduler.ParseSchedule(scheduler.CronSchedule, scheduler.Timezone)
+	if err != nil {
+		log.Fatalf("❌ Invalid CRON_SCHEDULE %q: %v", scheduler.CronSchedule, err)
+	}
+	scheduler.DefaultSchedule = schedule
+	scheduler.CatchUpWindow = envDuration("CATCHUP_WINDOW", 0)
+	scheduler.LastSuccess = scheduler.LoadRunTimes(envString("SCHEDULE_STATE_FILE", "schedule_state.json"))
+
+	scheduler.MaxRuns = envInt("MAX_RUNS", 0)
+	scheduler.StartupRunDelay = envDuration("STARTUP_RUN_DELAY", 0)
+
+	if raw := envString("PAYLOAD_METADATA", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &generator.PayloadMetadata); err != nil {
+			log.Fatal("❌ PAYLOAD_METADATA must be a JSON object:", err)
+		}
+	}
+
+	generator.ExtractionFallback = envString("EXTRACTION_FALLBACK", generator.ExtractionFallback)
+	switch generator.ExtractionFallback {
+	case "none", "retry", "deadletter", "raw":
+	default:
+		log.Fatalf("❌ Unknown EXTRACTION_FALLBACK %q (want none, retry, deadletter or raw)", generator.ExtractionFallback)
+	}
+	generator.ExtractionRetries = envInt("EXTRACTION_RETRIES", generator.ExtractionRetries)
+	generator.DeadLetterFile = envString("DEAD_LETTER_FILE", generator.DeadLetterFile)
+	generator.ArchiveMaxSize = envSize("ARCHIVE_MAX_SIZE", generator.ArchiveMaxSize)
+	if generator.ArchiveKeep = envInt("ARCHIVE_KEEP", generator.ArchiveKeep); generator.ArchiveKeep < 0 {
+		log.Fatalf("❌ ARCHIVE_KEEP must not be negative, got %d", generator.ArchiveKeep)
+	}
+	generator.BackupFile = envString("BACKUP_FILE", generator.BackupFile)
+	generator.StoreRaw = envBool("STORE_RAW", generator.StoreRaw)
+	generator.RawArchiveFile = envString("RAW_ARCHIVE_FILE", generator.RawArchiveFile)
+
+	runctx.RequestIDHeader = envString("REQUEST_ID_HEADER", runctx.RequestIDHeader)
+
+	Warmup = envBool("WARMUP", Warmup)
+	// LLM_MAX_RETRIES replaces GROQ_MAX_RETRIES, which is still read.
+	llmConfig.MaxRetries = envInt("LLM_MAX_RETRIES", envInt("GROQ_MAX_RETRIES", llmConfig.MaxRetries))
+	llmConfig.RetryBackoff = envDuration("GROQ_RETRY_BACKOFF", llmConfig.RetryBackoff)
+	retry.CircuitBreakerThreshold = envInt("CIRCUIT_BREAKER_THRESHOLD", retry.CircuitBreakerThreshold)
+	retry.CircuitBreakerCooldown = envDuration("CIRCUIT_BREAKER_COOLDOWN", retry.CircuitBreakerCooldown)
+	llmConfig.Timeout = envDuration("LLM_TIMEOUT", llmConfig.Timeout)
+	scheduler.GenerateRetryAfter = llmConfig.Timeout
+	rpm := envInt("LLM_RPM", 0)
+	if rpm < 0 {
+		log.Fatal("❌ LLM_RPM must not be negative")
+	}
+	llmConfig.RateLimit = llm.NewRateLimit(rpm)
+	buildLLM()
+	generator.ExtractTimeout = envDuration("EXTRACT_TIMEOUT", generator.ExtractTimeout)
+	output.SetBackendTimeout(envDuration("BACKEND_TIMEOUT", output.BackendTimeout))
+	// RUN_DEADLINE is the older name for RUN_TIMEOUT.
+	generator.RunDeadline = envDuration("RUN_TIMEOUT", envDuration("RUN_DEADLINE", generator.RunDeadline))
+	scheduler.ShutdownTimeout = envDuration("SHUTDOWN_TIMEOUT", scheduler.ShutdownTimeout)
+
+	generator.ExampleCount = envInt("EXAMPLE_COUNT", generator.ExampleCount)
+	if generator.ExampleCount < 1 {
+		log.Fatal("❌ EXAMPLE_COUNT must be at least 1")
+	}
+	generator.SanitizeExample = envBool("SANITIZE_EXAMPLE", generator.SanitizeExample)
+	generator.ExampleMaxLength = envInt("EXAMPLE_MAX_LENGTH", generator.ExampleMaxLength)
+
+	output.BackendRedirects = envString("BACKEND_REDIRECTS", output.BackendRedirects)
+	if output.BackendRedirects != "follow" && output.BackendRedirects != "error" {
+		log.Fatalf("❌ Unknown BACKEND_REDIRECTS %q (want follow or error)", output.BackendRedirects)
+	}
+
+	generator.QualityReview = envBool("QUALITY_REVIEW", generator.QualityReview)
+	generator.QualityMinScore = envInt("QUALITY_MIN_SCORE", generator.QualityMinScore)
+	generator.QualityRegenAttempts = envInt("QUALITY_REGEN_ATTEMPTS", generator.QualityRegenAttempts)
+	generator.QualityFailMode = envString("QUALITY_FAIL_MODE", generator.QualityFailMode)
+	if generator.QualityFailMode != "open" && generator.QualityFailMode != "closed" {
+		log.Fatalf("❌ Unknown QUALITY_FAIL_MODE %q (want open or closed)", generator.QualityFailMode)
+	}
+	generator.ReviewPromptPath = envString("REVIEW_PROMPT_PATH", "")
+
+	output.BackendContentType = envString("BACKEND_CONTENT_TYPE", output.BackendContentType)
+	output.BackendAPIToken = envString("BACKEND_API_TOKEN", "")
+	output.BackendAPIKey = envString("BACKEND_API_KEY", "")
+	output.BackendAPIKeyHeader = envString("BACKEND_API_KEY_HEADER", output.BackendAPIKeyHeader)
+	if raw := envString("BACKEND_HEADERS", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &output.BackendHeaders); err != nil {
+			log.Fatal("❌ BACKEND_HEADERS must be a JSON object of strings:", err)
+		}
+	}
+	output.OAuthTokenURL = envString("BACKEND_OAUTH_TOKEN_URL", "")
+	output.OAuthClientID = envString("BACKEND_OAUTH_CLIENT_ID", "")
+	output.OAuthClientSecret = envString("BACKEND_OAUTH_CLIENT_SECRET", "")
+	output.OAuthScopes = strings.Fields(strings.ReplaceAll(envString("BACKEND_OAUTH_SCOPES", ""), ",", " "))
+	output.OAuthAudience = envString("BACKEND_OAUTH_AUDIENCE", "")
+	if output.OAuthTokenURL != "" && (output.OAuthClientID == "" || output.OAuthClientSecret == "") {
+		log.Fatal("❌ BACKEND_OAUTH_TOKEN_URL requires BACKEND_OAUTH_CLIENT_ID and BACKEND_OAUTH_CLIENT_SECRET")
+	}
+	if output.OAuthTokenURL != "" && output.BackendAPIToken != "" {
+		log.Fatal("❌ Set either BACKEND_API_TOKEN or BACKEND_OAUTH_TOKEN_URL, not both")
+	}
+	tlsCert, tlsKey, tlsCA := envString("BACKEND_TLS_CERT", ""), envString("BACKEND_TLS_KEY", ""), envString("BACKEND_TLS_CA", "")
+	if tlsCert != "" || tlsCA != "" || tlsKey != "" {
+		if err := output.ConfigureBackendTLS(tlsCert, tlsKey, tlsCA); err != nil {
+			log.Fatal("❌ Invalid backend TLS settings: ", err)
+		}
+		if tlsCert != "" {
+			log.Println("🔐 Backend requests use the client certificate", tlsCert)
+		}
+	}
+	output.BackendEncoding = envString("BACKEND_ENCODING", output.BackendEncoding)
+	output.BackendSuccessField = envString("BACKEND_SUCCESS_FIELD", output.BackendSuccessField)
+	output.BackendSoftRetries = envInt("BACKEND_SOFT_RETRIES", output.BackendSoftRetries)
+	output.BackendMaxRetries = envInt("BACKEND_MAX_RETRIES", output.BackendMaxRetries)
+	output.BackendRetryBackoff = envDuration("BACKEND_RETRY_BACKOFF", output.BackendRetryBackoff)
+	if output.BackendEncoding != "json" && output.BackendEncoding != "form" {
+		log.Fatalf("❌ Unknown BACKEND_ENCODING %q (want json or form)", output.BackendEncoding)
+	}
+	generator.GenerateSlug = envBool("GENERATE_SLUG", generator.GenerateSlug)
+	generator.IncludeCounts = envBool("INCLUDE_COUNTS", generator.IncludeCounts)
+	for _, rule := range []struct {
+		key string
+		re  **regexp.Regexp
+	}{{"TITLE_ALLOW_REGEX", &generator.TitleAllowRegex}, {"TITLE_DENY_REGEX", &generator.TitleDenyRegex}} {
+		if expr := envString(rule.key, ""); expr != "" {
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				log.Fatalf("❌ Invalid %s: %v", rule.key, err)
+			}
+			*rule.re = re
+		}
+	}
+	generator.TitleRuleAction = envString("TITLE_RULE_ACTION", generator.TitleRuleAction)
+	if generator.TitleRuleAction != "fail" && generator.TitleRuleAction != "regenerate" {
+		log.Fatalf("❌ Unknown TITLE_RULE_ACTION %q (want fail or regenerate)", generator.TitleRuleAction)
+	}
+	generator.TitleRegenAttempts = envInt("TITLE_REGEN_ATTEMPTS", generator.TitleRegenAttempts)
+
+	if spec := envString("PLACEHOLDER_PATTERNS", ""); spec != "" {
+		for _, expr := range strings.Split(spec, ",") {
+			if expr = strings.TrimSpace(expr); expr == "" {
+				continue
+			}
+			re, err := regexp.Compile("(?i)" + expr)
+			if err != nil {
+				log.Fatalf("❌ Invalid PLACEHOLDER_PATTERNS entry %q: %v", expr, err)
+			}
+			generator.PlaceholderPatterns = append(generator.PlaceholderPatterns, re)
+		}
+	}
+	generator.PlaceholderAction = envString("PLACEHOLDER_ACTION", generator.PlaceholderAction)
+	if generator.PlaceholderAction != "fail" && generator.PlaceholderAction != "regenerate" {
+		log.Fatalf("❌ Unknown PLACEHOLDER_ACTION %q (want fail or regenerate)", generator.PlaceholderAction)
+	}
+	generator.PlaceholderRegenAttempts = envInt("PLACEHOLDER_REGEN_ATTEMPTS", generator.PlaceholderRegenAttempts)
+
+	for _, word := range strings.Split(envString("BANNED_WORDS", ""), ",") {
+		if word = strings.TrimSpace(word); word != "" {
+			generator.ContentRules = append(generator.ContentRules, generator.BannedWordRule(word))
+		}
+	}
+	if spec := envString("CONTENT_DENY_PATTERNS", ""); spec != "" {
+		for _, expr := range strings.Split(spec, ",") {
+			if expr = strings.TrimSpace(expr); expr == "" {
+				continue
+			}
+			re, err := regexp.Compile("(?i)" + expr)
+			if err != nil {
+				log.Fatalf("❌ Invalid CONTENT_DENY_PATTERNS entry %q: %v", expr, err)
+			}
+			generator.ContentRules = append(generator.ContentRules, generator.PatternRule(re))
+		}
+	}
+	generator.DescriptionMinLength = envInt("DESCRIPTION_MIN_LENGTH", generator.DescriptionMinLength)
+	generator.PromptMinLength = envInt("PROMPT_MIN_LENGTH", generator.PromptMinLength)
+	generator.ModerationAction = envString("MODERATION_ACTION", generator.ModerationAction)
+	if generator.ModerationAction != "fail" && generator.ModerationAction != "regenerate" {
+		log.Fatalf("❌ Unknown MODERATION_ACTION %q (want fail or regenerate)", generator.ModerationAction)
+	}
+	generator.ModerationRegenAttempts = envInt("MODERATION_REGEN_ATTEMPTS", generator.ModerationRegenAttempts)
+
+	scheduler.HTTPGenerateConcurrency = envInt("HTTP_GENERATE_CONCURRENCY", scheduler.HTTPGenerateConcurrency)
+	if scheduler.HTTPGenerateConcurrency < 1 {
+		log.Fatal("❌ HTTP_GENERATE_CONCURRENCY must be at least 1")
+	}
+
+	if envBool("BACKEND_FORCE_HTTP1", false) {
+		output.ForceBackendHTTP1()
+		log.Println("🐢 Backend requests limited to HTTP/1.1")
+	}
+
+	scheduler.BackendBulk = envBool("BACKEND_BULK", scheduler.BackendBulk)
+	output.BackendBulkURL = envString("BACKEND_BULK_URL", "")
+	if scheduler.BackendBulk && output.BackendBulkURL == "" {
+		log.Fatal("❌ BACKEND_BULK=true requires BACKEND_BULK_URL")
+	}
+
+	scheduler.HealthPort = envString("HEALTH_PORT", scheduler.HealthPort)
+	scheduler.AdminToken = envString("ADMIN_TOKEN", "")
+
+	output.QueueURL = envString("QUEUE_URL", "")
+	defaultOutputs := "backend"
+	if output.QueueURL != "" {
+		defaultOutputs = "backend,queue"
+	}
+	if output.Outputs, err = output.ParseOutputs(envString("OUTPUTS", defaultOutputs)); err != nil {
+		log.Fatalf("❌ Invalid OUTPUTS: %v", err)
+	}
+	output.WebhookURL = envString("WEBHOOK_URL", "")
+	output.WebhookMethod = strings.ToUpper(envString("WEBHOOK_METHOD", output.WebhookMethod))
+	if raw := envString("WEBHOOK_HEADERS", ""); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &output.WebhookHeaders); err != nil {
+			log.Fatal("❌ WEBHOOK_HEADERS must be a JSON object of strings:", err)
+		}
+	}
+	output.WebhookToken = envString("WEBHOOK_TOKEN", "")
+	output.WebhookBasicAuth = envString("WEBHOOK_BASIC_AUTH", "")
+	output.SlackWebhookURL = envString("SLACK_WEBHOOK_URL", "")
+	output.DiscordWebhookURL = envString("DISCORD_WEBHOOK_URL", "")
+	output.RabbitMQURL = envString("RABBITMQ_URL", "")
+	output.RabbitMQExchange = envString("RABBITMQ_EXCHANGE", "")
+	output.RabbitMQRoutingKey = envString("RABBITMQ_ROUTING_KEY", output.RabbitMQRoutingKey)
+
+	approval.Enabled = envBool("APPROVAL_MODE", false)
+	approval.BaseURL = envString("APPROVAL_BASE_URL", "")
+	approval.Expire = envDuration("APPROVAL_EXPIRE", approval.Expire)
+	approval.Retention = envDuration("APPROVAL_RETENTION", approval.Retention)
+	approvalFile := envString("APPROVAL_FILE", "approvals.json")
+	output.ReviewChannel = strings.ToLower(envString("APPROVAL_CHANNEL", output.ReviewChannel))
+	output.ReviewSlackWebhookURL = envString("APPROVAL_SLACK_WEBHOOK_URL", output.SlackWebhookURL)
+	output.ReviewWebhookURL = envString("APPROVAL_WEBHOOK_URL", "")
+	output.SMTPHost = envString("SMTP_HOST", "")
+	output.SMTPPort = envInt("SMTP_PORT", output.SMTPPort)
+	output.SMTPUser = envString("SMTP_USER", "")
+	output.SMTPPassword = envString("SMTP_PASSWORD", "")
+	output.ReviewFrom = envString("APPROVAL_EMAIL_FROM", "")
+	output.ReviewTo = nil
+	for _, addr := range strings.Split(envString("APPROVAL_EMAIL_TO", ""), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			output.ReviewTo = append(output.ReviewTo, addr)
+		}
+	}
+	if approval.Enabled {
+		if err := output.CheckReviewChannel(); err != nil {
+			log.Fatalf("❌ Invalid APPROVAL_CHANNEL: %v", err)
+		}
+		if approval.Expire <= 0 {
+			log.Fatal("❌ APPROVAL_EXPIRE must be positive")
+		}
+		approval.Queue = approval.Load(approvalFile)
+	}
+
+	if output.Publishers, err = output.ParsePublishers(envString("PUBLISHERS", "")); err != nil {
+		log.Fatalf("❌ Invalid PUBLISHERS: %v", err)
+	}
+
+	if sources.URLs, err = sources.ParseURLs(envString("SOURCES", "")); err != nil {
+		log.Fatalf("❌ Invalid SOURCES: %v", err)
+	}
+	sources.Timeout = envDuration("SOURCES_TIMEOUT", sources.Timeout)
+	sources.CacheTTL = envDuration("SOURCES_CACHE_TTL", sources.CacheTTL)
+	sources.MaxAge = envDuration("SOURCES_MAX_AGE", sources.MaxAge)
+	if sources.MaxItems = envInt("SOURCES_MAX_ITEMS", sources.MaxItems); sources.MaxItems < 1 {
+		log.Fatal("❌ SOURCES_MAX_ITEMS must be at least 1")
+	}
+
+	if generator.Locales, err = generator.ParseLocales(envString("LOCALES", "")); err != nil {
+		log.Fatalf("❌ Invalid LOCALES: %v", err)
+	}
+	generator.LocaleMode = envString("LOCALE_MODE", generator.LocaleMode)
+	if generator.LocaleMode != "generate" && generator.LocaleMode != "translate" {
+		log.Fatalf("❌ Unknown LOCALE_MODE %q (want generate or translate)", generator.LocaleMode)
+	}
+	output.LinkedInAccessToken = envString("LINKEDIN_ACCESS_TOKEN", "")
+	output.LinkedInAuthor = envString("LINKEDIN_AUTHOR", "")
+	output.XAccessToken = envString("X_ACCESS_TOKEN", "")
+	output.MastodonURL = envString("MASTODON_URL", "")
+	output.MastodonAccessToken = envString("MASTODON_ACCESS_TOKEN", "")
+	if output.MastodonCharLimit = envInt("MASTODON_CHAR_LIMIT", output.MastodonCharLimit); output.MastodonCharLimit < 50 {
+		log.Fatalf("❌ MASTODON_CHAR_LIMIT must be at least 50, got %d", output.MastodonCharLimit)
+	}
+
+	output.Sinks = output.BuildSinks(output.BackendAPI, output.Outputs, output.Publishers)
+	if err := scheduler.SetupJobs(); err != nil {
+		log.Fatal("❌ Invalid config file: ", err)
+	}
+	if output.HasOutput(output.Outputs, "queue") {
+		log.Println("📬 Queue sink enabled:", output.QueueURL)
+	}
+	if len(output.Outputs) != 1 || output.Outputs[0] != "backend" {
+		log.Println("📤 Sending to", strings.Join(output.Outputs, ", "))
+	}
+	if len(output.Publishers) > 0 {
+		log.Println("📣 Publishing to", strings.Join(output.Publishers, ", "))
+	}
+}
+
+// envValue records key and its default for --print-env-template and
+// returns the trimmed value from the environment, falling back to the
+// config file, with secret references resolved.
+func envValue(key string, def interface{}) string {
+	if !envKnown[key] {
+		envKnown[key] = true
+		envDefaults = append(envDefaults, envDefault{key, fmt.Sprint(def)})
+	}
+	if envTemplateOnly {
+		return ""
+	}
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		v = configValues[key]
+	}
+	return resolveSecret(key, v)
+}
+
+func envString(key, def string) string {
+	if v := envValue(key, def); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	v := envValue(key, def)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %d", key, v, def)
+		return def
+	}
+	return n
+}
+
+func envBool(key string, def bool) bool {
+	v := envValue(key, def)
+	if v == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %t", key, v, def)
+		return def
+	}
+	return b
+}
+
+func envFloat(key string, def float64) float64 {
+	v := envValue(key, def)
+	if v == "" {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %g", key, v, def)
+		return def
+	}
+	return f
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := envValue(key, def)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("⚠️ Invalid %s=%q, using default %s", key, v, def)
+		return def
+	}
+	return d
+}
+
+// envSize reads a byte size such as 1048576, 512K or 10MB.
+func envSize(key string, def int64) int64 {
+	v := strings.ToUpper(envValue(key, def))
+	if v == "" {
+		return def
+	}
+	mult := int64(1)
+	for _, u := range []struct {
+		suffix string
+		mult   int64
+	}{{"GB", 1 << 30}, {"G", 1 << 30}, {"MB", 1 << 20}, {"M", 1 << 20}, {"KB", 1 << 10}, {"K", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(v, u.suffix) {
+			v, mult = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.mult
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		log.Printf("⚠️ Invalid %s=%q, using default %d", key, os.Getenv(key), def)
+		return def
+	}
+	return n * mult
+}
+
+var Warmup bool
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	yaml "gopkg.in/yaml.v3"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/scheduler"
+)
+
+// fileConfig is the layout of config.yaml / config.json. Every field maps
+// onto an environment variable, and a variable that is set in the
+// environment wins over the file.
+type fileConfig struct {
+	Schedule    string   `json:"schedule" yaml:"schedule"`
+	Timezone    string   `json:"timezone" yaml:"timezone"`
+	Provider    string   `json:"provider" yaml:"provider"`
+	Model       string   `json:"model" yaml:"model"`
+	Endpoint    string   `json:"endpoint" yaml:"endpoint"`
+	Temperature *float64 `json:"temperature" yaml:"temperature"`
+	Sectors     []string `json:"sectors" yaml:"sectors"`
+	Sources     []string `json:"sources" yaml:"sources"`
+	ContentType string   `json:"contentType" yaml:"contentType"`
+
+	Backend struct {
+		URL          string `json:"url" yaml:"url"`
+		Token        string `json:"token" yaml:"token"`
+		APIKey       string `json:"apiKey" yaml:"apiKey"`
+		APIKeyHeader string `json:"apiKeyHeader" yaml:"apiKeyHeader"`
+		OAuth        struct {
+			TokenURL     string   `json:"tokenUrl" yaml:"tokenUrl"`
+			ClientID     string   `json:"clientId" yaml:"clientId"`
+			ClientSecret string   `json:"clientSecret" yaml:"clientSecret"`
+			Scopes       []string `json:"scopes" yaml:"scopes"`
+		} `json:"oauth" yaml:"oauth"`
+		TLS struct {
+			Cert string `json:"cert" yaml:"cert"`
+			Key  string `json:"key" yaml:"key"`
+			CA   string `json:"ca" yaml:"ca"`
+		} `json:"tls" yaml:"tls"`
+	} `json:"backend" yaml:"backend"`
+
+	Locales    []string `json:"locales" yaml:"locales"`
+	Outputs    []string `json:"outputs" yaml:"outputs"`
+	Publishers []string `json:"publishers" yaml:"publishers"`
+
+	Timeouts struct {
+		LLM     string `json:"llm" yaml:"llm"`
+		Extract string `json:"extract" yaml:"extract"`
+		Backend string `json:"backend" yaml:"backend"`
+		Run     string `json:"run" yaml:"run"`
+	} `json:"timeouts" yaml:"timeouts"`
+
+	Retry struct {
+		MaxRetries        *int   `json:"maxRetries" yaml:"maxRetries"`
+		Backoff           string `json:"backoff" yaml:"backoff"`
+		BackendMaxRetries *int   `json:"backendMaxRetries" yaml:"backendMaxRetries"`
+		BackendBackoff    string `json:"backendBackoff" yaml:"backendBackoff"`
+		BreakerThreshold  *int   `json:"breakerThreshold" yaml:"breakerThreshold"`
+		BreakerCooldown   string `json:"breakerCooldown" yaml:"breakerCooldown"`
+	} `json:"retry" yaml:"retry"`
+
+	// Env sets any other variable by name.
+	Env map[string]string `json:"env" yaml:"env"`
+
+	Jobs []scheduler.JobConfig `json:"jobs" yaml:"jobs"`
+}
+
+// configValues holds the settings read from the config file, keyed by
+// environment variable.
+var configValues = map[string]string{}
+
+var defaultConfigFiles = []string{"config.yaml", "config.yml", "config.json"}
+
+// loadConfigFile reads an explicit --config / CONFIG_FILE path, or the first
+// of ./config.yaml, ./config.yml and ./config.json that exists. Invalid
+// files stop the program with every problem listed.
+func loadConfigFile(path string) {
+	if path == "" {
+		for _, name := range defaultConfigFiles {
+			if _, err := os.Stat(name); err == nil {
+				path = name
+				break
+			}
+		}
+		if path == "" {
+			return
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatalf("❌ Could not read config file %s: %v", path, err)
+	}
+	var cfg fileConfig
+	if err := decodeConfigFile(path, data, &cfg); err != nil {
+		log.Fatalf("❌ Could not parse config file %s: %v", path, err)
+	}
+	values, err := cfg.values()
+	if err != nil {
+		log.Fatalf("❌ Invalid config file %s: %v", path, err)
+	}
+	jobs, err := scheduler.ParseJobs(cfg.Jobs)
+	if err != nil {
+		log.Fatalf("❌ Invalid config file %s: %v", path, err)
+	}
+	configValues, scheduler.Jobs = values, jobs
+	log.Println("📄 Loaded config from", path)
+}
+
+func decodeConfigFile(path string, data []byte, cfg *fileConfig) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		return dec.Decode(cfg)
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		return dec.Decode(cfg)
+	}
+	return fmt.Errorf("unsupported extension %q (want .yaml, .yml or .json)", filepath.Ext(path))
+}
+
+// values validates the file and flattens it into environment variables.
+func (c fileConfig) values() (map[string]string, error) {
+	var problems []error
+	out := map[string]string{}
+
+	set := func(key, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			out[key] = value
+		}
+	}
+	setDuration := func(field, key, value string) {
+		if value == "" {
+			return
+		}
+		if d, err := time.ParseDuration(value); err != nil || d < 0 {
+			problems = append(problems, fmt.Errorf("%s: %q is not a valid duration", field, value))
+			return
+		}
+		set(key, value)
+	}
+	setCount := func(field, key string, n *int) {
+		if n == nil {
+			return
+		}
+		if *n < 0 {
+			problems = append(problems, fmt.Errorf("%s: must not be negative", field))
+			return
+		}
+		set(key, strconv.Itoa(*n))
+	}
+
+	if c.Schedule != "" {
+		if _, err := cron.ParseStandard(c.Schedule); err != nil {
+			problems = append(problems, fmt.Errorf("schedule: %v", err))
+		}
+		set("CRON_SCHEDULE", c.Schedule)
+	}
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			problems = append(problems, fmt.Errorf("timezone: %v", err))
+		}
+		set("CRON_TZ", c.Timezone)
+	}
+	set("LLM_PROVIDER", c.Provider)
+	set("CONTENT_TYPE", c.ContentType)
+	set("LLM_MODEL", c.Model)
+	set("LLM_ENDPOINT", c.Endpoint)
+	if t := c.Temperature; t != nil {
+		if *t < 0 || *t > 2 {
+			problems = append(problems, fmt.Errorf("temperature: got %g, want 0–2", *t))
+		}
+		set("LLM_TEMPERATURE", strconv.FormatFloat(*t, 'g', -1, 64))
+	}
+	if c.Sectors != nil {
+		for i, s := range c.Sectors {
+			if strings.TrimSpace(s) == "" || strings.Contains(s, ",") {
+				problems = append(problems, fmt.Errorf("sectors[%d]: %q must be non-empty and contain no commas", i, s))
+			}
+		}
+		if len(c.Sectors) == 0 {
+			problems = append(problems, fmt.Errorf("sectors: must list at least one sector"))
+		}
+		set("SECTORS", strings.Join(c.Sectors, ","))
+	}
+
+	set("BACKEND_API_URL", c.Backend.URL)
+	set("BACKEND_API_TOKEN", c.Backend.Token)
+	set("BACKEND_API_KEY", c.Backend.APIKey)
+	set("BACKEND_API_KEY_HEADER", c.Backend.APIKeyHeader)
+	set("BACKEND_OAUTH_TOKEN_URL", c.Backend.OAuth.TokenURL)
+	set("BACKEND_OAUTH_CLIENT_ID", c.Backend.OAuth.ClientID)
+	set("BACKEND_OAUTH_CLIENT_SECRET", c.Backend.OAuth.ClientSecret)
+	set("BACKEND_OAUTH_SCOPES", strings.Join(c.Backend.OAuth.Scopes, " "))
+	set("BACKEND_TLS_CERT", c.Backend.TLS.Cert)
+	set("BACKEND_TLS_KEY", c.Backend.TLS.Key)
+	set("BACKEND_TLS_CA", c.Backend.TLS.CA)
+	set("LOCALES", strings.Join(c.Locales, ","))
+	set("SOURCES", strings.Join(c.Sources, ","))
+	set("OUTPUTS", strings.Join(c.Outputs, ","))
+	set("PUBLISHERS", strings.Join(c.Publishers, ","))
+
+	setDuration("timeouts.llm", "LLM_TIMEOUT", c.Timeouts.LLM)
+	setDuration("timeouts.extract", "EXTRACT_TIMEOUT", c.Timeouts.Extract)
+	setDuration("timeouts.backend", "BACKEND_TIMEOUT", c.Timeouts.Backend)
+	setDuration("timeouts.run", "RUN_TIMEOUT", c.Timeouts.Run)
+
+	setCount("retry.maxRetries", "GROQ_MAX_RETRIES", c.Retry.MaxRetries)
+	setDuration("retry.backoff", "GROQ_RETRY_BACKOFF", c.Retry.Backoff)
+	setCount("retry.backendMaxRetries", "BACKEND_MAX_RETRIES", c.Retry.BackendMaxRetries)
+	setDuration("retry.backendBackoff", "BACKEND_RETRY_BACKOFF", c.Retry.BackendBackoff)
+	setCount("retry.breakerThreshold", "CIRCUIT_BREAKER_THRESHOLD", c.Retry.BreakerThreshold)
+	setDuration("retry.breakerCooldown", "CIRCUIT_BREAKER_COOLDOWN", c.Retry.BreakerCooldown)
+
+	keys := make([]string, 0, len(c.Env))
+	for k := range c.Env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, dup := out[k]; dup {
+			problems = append(problems, fmt.Errorf("env.%s: already set by a dedicated field", k))
+			continue
+		}
+		set(k, c.Env[k])
+	}
+
+	return out, generator.JoinProblems(problems)
+}
+
+// warnUnknownConfigKeys flags env entries in the config file that no
+// setting reads, which are usually typos.
+func warnUnknownConfigKeys() {
+	var unknown []string
+	for k := range configValues {
+		if !envKnown[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		log.Printf("⚠️ Config file sets %s, which is not a known setting", k)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+
+	"promptcraft-groq/pkg/generator"
+)
+
+func loadPromptFile(path string) (generator.PromptResponse, error) {
+	var p generator.PromptResponse
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// diffPrompts writes a field-by-field comparison of two prompts.
+func diffPrompts(w io.Writer, a, b generator.PromptResponse) {
+	changed := false
+
+	text := func(field, before, after string) {
+		if before == after {
+			return
+		}
+		changed = true
+		fmt.Fprintf(w, "~ %s:\n  - %s\n  + %s\n", field, before, after)
+	}
+	list := func(field string, before, after []string) {
+		added, removed := sliceDiff(before, after)
+		if len(added) == 0 && len(removed) == 0 {
+			return
+		}
+		changed = true
+		fmt.Fprintf(w, "~ %s:\n", field)
+		for _, v := range removed {
+			fmt.Fprintf(w, "  - %s\n", v)
+		}
+		for _, v := range added {
+			fmt.Fprintf(w, "  + %s\n", v)
+		}
+	}
+
+	text("title", a.Title, b.Title)
+	text("description", a.Description, b.Description)
+	text("prompt", a.Prompt, b.Prompt)
+	list("tags", a.Tags, b.Tags)
+	list("useCases", a.UseCases, b.UseCases)
+	if !reflect.DeepEqual(a.Example, b.Example) {
+		before, _ := json.Marshal(a.Example)
+		after, _ := json.Marshal(b.Example)
+		text("example", string(before), string(after))
+	}
+	text("sector", a.Sector, b.Sector)
+	text("templateVersion", a.TemplateVersion, b.TemplateVersion)
+
+	if !changed {
+		fmt.Fprintln(w, "no differences")
+	}
+}
+
+func sliceDiff(before, after []string) (added, removed []string) {
+	inBefore := make(map[string]bool, len(before))
+	for _, v := range before {
+		inBefore[v] = true
+	}
+	inAfter := make(map[string]bool, len(after))
+	for _, v := range after {
+		inAfter[v] = true
+		if !inBefore[v] {
+			added = append(added, v)
+		}
+	}
+	for _, v := range before {
+		if !inAfter[v] {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
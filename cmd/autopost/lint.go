@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"promptcraft-groq/pkg/generator"
+)
+
+// lintArchive runs every entry of a JSONL archive through validate() and
+// prints the failures. It returns the number of entries that failed.
+func lintArchive(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	total, failed := 0, 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		total++
+
+		var p generator.PromptResponse
+		if err := json.Unmarshal([]byte(text), &p); err != nil {
+			failed++
+			fmt.Printf("line %d: invalid JSON: %v\n", line, err)
+			continue
+		}
+		if err := p.Validate(); err != nil {
+			failed++
+			fmt.Printf("line %d (%q):\n", line, p.Title)
+			for _, reason := range strings.Split(err.Error(), "\n") {
+				fmt.Printf("  - %s\n", reason)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return failed, err
+	}
+
+	fmt.Printf("\n%d of %d entries failed validation\n", failed, total)
+	return failed, nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"log"
+	"log/slog"
+	"os"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	// LogFormat is "plain" (classic log lines), "text" (key=value) or
+	// "json".
+	LogFormat = "plain"
+	LogLevel  = "info"
+)
+
+// setupLogging builds the logger from LOG_FORMAT and LOG_LEVEL and routes
+// the standard log package through it, so every line shares one format.
+func setupLogging() {
+	var level slog.Level
+	switch LogLevel {
+	case "debug":
+		level = slog.LevelDebug
+	case "info":
+		level = slog.LevelInfo
+	case "warn":
+		level = slog.LevelWarn
+	case "error":
+		level = slog.LevelError
+	default:
+		log.Fatalf("❌ Unknown LOG_LEVEL %q (want debug, info, warn or error)", LogLevel)
+	}
+
+	var h slog.Handler
+	switch LogFormat {
+	case "plain":
+		h = runctx.NewPlainHandler(os.Stderr, level)
+	case "text":
+		h = slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: level})
+	default:
+		log.Fatalf("❌ Unknown LOG_FORMAT %q (want plain, text or json)", LogFormat)
+	}
+	runctx.Logger = slog.New(h)
+
+	log.SetFlags(0)
+	log.SetOutput(runctx.LogBridge{})
+}
@@ -0,0 +1,177 @@
+// Command autopost generates prompts with an LLM and posts them to a
+// backend on a schedule.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	// Embedded so CRON_TZ works in images without a zoneinfo database.
+	_ "time/tzdata"
+
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/scheduler"
+)
+
+func main() {
+	command, args := splitCommand(os.Args[1:])
+	flag.Usage = usage
+	lintFile := flag.String("lint", "", "validate every entry of a JSONL archive and exit")
+	flag.BoolVar(&generator.DryRun, "dry-run", false, "generate one prompt, print it instead of sending, and exit non-zero on failure (or DRY_RUN=true)")
+	once := flag.Bool("once", false, "run every job once, then exit non-zero if any prompt failed")
+	flag.BoolVar(&generator.Pretty, "pretty", false, "indent (and colorize on a terminal) --dry-run output")
+	titlesSector := flag.String("titles", "", "print N title ideas for a sector and exit (usage: --titles <sector> <n>)")
+	listModelsFlag := flag.Bool("models", false, "check the API key, list the available models and exit")
+	diffFile := flag.String("diff", "", "compare two prompt JSON files field by field (usage: --diff <fileA> <fileB>)")
+	envFile := flag.String("env-file", os.Getenv("ENV_FILE"), "load environment variables from this file instead of ./.env")
+	envTemplate := flag.Bool("print-env-template", false, "print a commented sample .env with every supported variable and exit")
+	batchCount := flag.Int("count", 0, "prompts to generate with the batch command")
+	batchConcurrency := flag.Int("concurrency", 0, "generations in flight with the batch command (default MAX_CONCURRENCY)")
+	batchJob := flag.String("job", "", "job the batch command generates for (default the first scheduled job)")
+	configFile := flag.String("config", "", "load settings from this YAML or JSON file (default $CONFIG_FILE, then ./config.yaml, ./config.yml or ./config.json)")
+	flag.CommandLine.Parse(args)
+
+	if *envTemplate {
+		printEnvTemplate(os.Stdout)
+		return
+	}
+
+	loadEnvFile(*envFile)
+	if *configFile == "" {
+		*configFile = os.Getenv("CONFIG_FILE")
+	}
+	loadConfigFile(*configFile)
+
+	if *diffFile != "" {
+		if flag.NArg() < 1 {
+			log.Fatal("❌ Usage: --diff <fileA> <fileB>")
+		}
+		a, err := loadPromptFile(*diffFile)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		b, err := loadPromptFile(flag.Arg(0))
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		diffPrompts(os.Stdout, a, b)
+		return
+	}
+
+	if *lintFile != "" {
+		loadConfig()
+		failed, err := lintArchive(*lintFile)
+		if err != nil {
+			log.Fatal("❌ Lint failed:", err)
+		}
+		if failed > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	loadConfig()
+	warnUnknownConfigKeys()
+	watchSecrets(context.Background())
+
+	if command == "validate-config" {
+		runValidateConfig()
+		return
+	}
+
+	log.Printf("🤖 Using %s (%s), API key loaded: %t", generator.LLM.Name(), generator.LLM.Model(), llmConfig.MissingAPIKey() == "")
+	log.Println("🔗 BACKEND_API:", output.BackendAPI)
+
+	if *listModelsFlag {
+		if key := llmConfig.MissingAPIKey(); key != "" {
+			log.Fatalf("❌ Environment variable %s not set", key)
+		}
+		models, err := llm.ListModels(context.Background(), generator.LLM)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		for _, id := range models {
+			fmt.Println(id)
+		}
+		return
+	}
+
+	if *titlesSector != "" {
+		if key := llmConfig.MissingAPIKey(); key != "" {
+			log.Fatalf("❌ Environment variable %s not set", key)
+		}
+		n, err := strconv.Atoi(flag.Arg(0))
+		if err != nil || n < 1 {
+			log.Fatal("❌ Usage: --titles <sector> <n>")
+		}
+		titles, err := generator.GenerateTitles(context.Background(), *titlesSector, n)
+		if err != nil {
+			log.Fatal("❌ Failed to generate titles:", err)
+		}
+		for i, title := range titles {
+			fmt.Printf("%d. %s\n", i+1, title)
+		}
+		return
+	}
+
+	if command == "replay" {
+		runReplay()
+		return
+	}
+
+	if command == "batch" {
+		if key := llmConfig.MissingAPIKey(); key != "" {
+			log.Fatalf("❌ Environment variable %s not set", key)
+		}
+		if output.BackendAPI == "" && output.HasOutput(output.Outputs, "backend") && !generator.DryRun {
+			log.Fatal("❌ BACKEND_API_URL not set (in the environment or as backend.url in the config file)")
+		}
+		if *batchConcurrency == 0 {
+			*batchConcurrency = scheduler.MaxConcurrency
+		}
+		runBatchCommand(*batchJob, *batchCount, *batchConcurrency)
+		return
+	}
+
+	if key := llmConfig.MissingAPIKey(); key != "" {
+		log.Fatalf("❌ Environment variable %s not set", key)
+	}
+	if output.BackendAPI == "" && output.HasOutput(output.Outputs, "backend") && !generator.DryRun {
+		log.Fatal("❌ BACKEND_API_URL not set (in the environment or as backend.url in the config file)")
+	}
+
+	if Warmup {
+		llm.Warmup(context.Background(), generator.LLM)
+	}
+
+	if generator.DryRun {
+		if _, err := generator.GenerateAndSend(context.Background()); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *once {
+		runCtx, cancelRuns := context.WithCancel(context.Background())
+		defer cancelRuns()
+		scheduler.WatchSignals(cancelRuns)
+		failed := scheduler.RunJobsOnce(runCtx)
+		state.FlushAll()
+		if failed > 0 {
+			log.Printf("❌ %d prompt(s) failed", failed)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := scheduler.Run(); err != nil {
+		log.Printf("⚠️ %v, exiting with status 1", err)
+		os.Exit(1)
+	}
+}
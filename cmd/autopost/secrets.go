@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"promptcraft-groq/internal/secrets"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/scheduler"
+)
+
+// SecretsRefreshInterval is how often secret references are re-read to pick
+// up rotated keys.
+var SecretsRefreshInterval = 5 * time.Minute
+
+// configureSecrets reads the secret stores' own settings. It runs before
+// any other setting is read, so those can reference the stores.
+func configureSecrets() {
+	secrets.VaultAddr = envString("VAULT_ADDR", secrets.VaultAddr)
+	secrets.VaultToken = envString("VAULT_TOKEN", "")
+	secrets.VaultNamespace = envString("VAULT_NAMESPACE", "")
+	secrets.AWSRegion = envString("AWS_REGION", secrets.AWSRegion)
+	secrets.AWS.AccessKeyID = envString("AWS_ACCESS_KEY_ID", "")
+	secrets.AWS.SecretAccessKey = envString("AWS_SECRET_ACCESS_KEY", "")
+	secrets.AWS.SessionToken = envString("AWS_SESSION_TOKEN", "")
+	secrets.AWSEndpoint = envString("AWS_SECRETS_MANAGER_ENDPOINT", "")
+	secrets.Timeout = envDuration("SECRETS_TIMEOUT", secrets.Timeout)
+	SecretsRefreshInterval = envDuration("SECRETS_REFRESH_INTERVAL", SecretsRefreshInterval)
+}
+
+// resolveSecret turns a vault://, awssm:// or file:// value into the secret
+// it names. <KEY>_FILE, the Docker secrets convention, is read when key
+// itself is unset.
+func resolveSecret(key, v string) string {
+	if v == "" {
+		if path := strings.TrimSpace(os.Getenv(key + "_FILE")); path != "" {
+			v = "file://" + path
+		}
+	}
+	if !secrets.IsRef(v) {
+		return v
+	}
+	resolved, err := secrets.Resolve(context.Background(), key, v)
+	if err != nil {
+		log.Fatalf("❌ Could not resolve %s from %s: %v", key, v, err)
+	}
+	return strings.TrimSpace(resolved)
+}
+
+// watchSecrets keeps secret references fresh until ctx is done, when any
+// setting came from a store.
+func watchSecrets(ctx context.Context) {
+	if !secrets.Tracked() || SecretsRefreshInterval <= 0 {
+		return
+	}
+	go secrets.Watch(ctx, SecretsRefreshInterval, applyRotatedSecrets)
+}
+
+// applyRotatedSecrets updates the credentials whose secret changed and
+// rebuilds what captured them, between runs. Other settings only change on
+// restart.
+func applyRotatedSecrets(changed map[string]string) {
+	generator.Reconfigure.Lock()
+	defer generator.Reconfigure.Unlock()
+
+	keys := make([]string, 0, len(changed))
+	for k := range changed {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	rebuild := false
+	for _, key := range keys {
+		v := strings.TrimSpace(changed[key])
+		switch key {
+		case "GROQ_API_KEY":
+			llmConfig.GroqAPIKey = v
+			rebuild = true
+		case "GROQ_HMAC_KEY_ID", "GROQ_HMAC_SECRET":
+			rebuild = true
+		case "OPENAI_API_KEY":
+			llmConfig.OpenAIAPIKey = v
+			rebuild = true
+		case "ANTHROPIC_API_KEY":
+			llmConfig.AnthropicAPIKey = v
+			rebuild = true
+		case "GEMINI_API_KEY":
+			llmConfig.GeminiAPIKey = v
+			rebuild = true
+		case "IMAGE_API_KEY":
+			llmConfig.Image.APIKey = v
+			rebuild = true
+		case "BACKEND_API_TOKEN":
+			output.BackendAPIToken = v
+		case "BACKEND_API_KEY":
+			output.BackendAPIKey = v
+		case "BACKEND_OAUTH_CLIENT_SECRET":
+			output.OAuthClientSecret = v
+		case "WEBHOOK_TOKEN":
+			output.WebhookToken = v
+		case "LINKEDIN_ACCESS_TOKEN":
+			output.LinkedInAccessToken = v
+		case "X_ACCESS_TOKEN":
+			output.XAccessToken = v
+		case "MASTODON_ACCESS_TOKEN":
+			output.MastodonAccessToken = v
+		case "GCS_ACCESS_TOKEN":
+			output.GCSAccessToken = v
+		default:
+			log.Printf("⚠️ Secret for %s was rotated; restart to apply it", key)
+			continue
+		}
+		log.Printf("🔑 Applied rotated secret for %s", key)
+	}
+
+	if rebuild {
+		llmConfig.GroqAuth = buildGroqAuth(groqAuthScheme)
+		buildLLM()
+		if err := scheduler.SetupJobs(); err != nil {
+			log.Println("⚠️ Could not rebuild jobs after a secret rotation:", err)
+		}
+	}
+}
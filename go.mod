@@ -1,7 +1,10 @@
 module promptcraft-groq
 
-go 1.20
+go 1.21
 
-require github.com/robfig/cron/v3 v3.0.1
-
-require github.com/joho/godotenv v1.5.1 // indirect
+require (
+	github.com/joho/godotenv v1.5.1
+	github.com/rabbitmq/amqp091-go v1.10.0
+	github.com/robfig/cron/v3 v3.0.1
+	gopkg.in/yaml.v3 v3.0.1
+)
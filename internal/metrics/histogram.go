@@ -0,0 +1,70 @@
+// Package metrics holds the latency histograms exposed on /metrics.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the histogram upper bounds, in seconds.
+var latencyBuckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60}
+
+// Histogram is a Prometheus-style cumulative histogram keyed by one label.
+type Histogram struct {
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// NewHistogram returns an empty histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{series: map[string]*histogramSeries{}}
+}
+
+type histogramSeries struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *Histogram) Observe(label string, d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := h.series[label]
+	if s == nil {
+		s = &histogramSeries{counts: make([]uint64, len(latencyBuckets))}
+		h.series[label] = s
+	}
+	secs := d.Seconds()
+	for i, le := range latencyBuckets {
+		if secs <= le {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += secs
+}
+
+func (h *Histogram) Write(w io.Writer, name, help, labelName string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	labels := make([]string, 0, len(h.series))
+	for l := range h.series {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	for _, l := range labels {
+		s := h.series[l]
+		for i, le := range latencyBuckets {
+			fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"%g\"} %d\n", name, labelName, l, le, s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket{%s=%q,le=\"+Inf\"} %d\n", name, labelName, l, s.count)
+		fmt.Fprintf(w, "%s_sum{%s=%q} %g\n", name, labelName, l, s.sum)
+		fmt.Fprintf(w, "%s_count{%s=%q} %d\n", name, labelName, l, s.count)
+	}
+}
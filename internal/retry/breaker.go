@@ -0,0 +1,159 @@
+package retry
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// CircuitBreakerThreshold is how many transient failures in a row open
+	// a circuit; 0 disables the breakers.
+	CircuitBreakerThreshold = 5
+	CircuitBreakerCooldown  = time.Minute
+)
+
+// Breaker stops calls to an upstream that keeps failing. Once open
+// it rejects calls for CircuitBreakerCooldown, then lets a single trial
+// call through: success closes it again, failure reopens it.
+type Breaker struct {
+	Name string
+	// Endpoint is the upstream the breaker guards, when it belongs to a
+	// Breakers set.
+	Endpoint string
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// circuitOpenError is returned instead of making a call while the circuit
+// is open.
+type circuitOpenError struct {
+	name  string
+	until time.Time
+}
+
+func (e *circuitOpenError) Error() string {
+	if wait := time.Until(e.until); wait > 0 {
+		return fmt.Sprintf("%s circuit open, calls paused for another %s", e.name, wait.Round(time.Second))
+	}
+	return fmt.Sprintf("%s circuit open, waiting for a trial call to finish", e.name)
+}
+
+// Allow reports whether a call may be made now.
+func (b *Breaker) Allow() error {
+	if CircuitBreakerThreshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.openUntil.IsZero():
+		return nil
+	case time.Now().Before(b.openUntil) || b.probing:
+		return &circuitOpenError{name: b.Name, until: b.openUntil}
+	}
+	b.probing = true
+	log.Printf("🔌 %s circuit half-open, letting a trial call through", b.Name)
+	return nil
+}
+
+// Open reports whether calls are currently being rejected. Once the
+// cooldown has passed it is only open while a trial call is in flight,
+// matching Allow.
+func (b *Breaker) Open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.openUntil.IsZero() && (time.Now().Before(b.openUntil) || b.probing)
+}
+
+// Record notes the outcome of an allowed call. failed should only be true
+// for errors that suggest the upstream is down, not for rejected requests.
+func (b *Breaker) Record(failed bool) {
+	if CircuitBreakerThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		if !b.openUntil.IsZero() {
+			log.Printf("🔌 %s circuit closed, calls resumed", b.Name)
+		}
+		b.failures, b.openUntil, b.probing = 0, time.Time{}, false
+		return
+	}
+
+	b.failures++
+	if b.probing || (b.openUntil.IsZero() && b.failures >= CircuitBreakerThreshold) {
+		b.openUntil = time.Now().Add(CircuitBreakerCooldown)
+		b.probing = false
+		log.Printf("🔌 %s circuit open after %d failure(s) in a row, pausing calls for %s", b.Name, b.failures, CircuitBreakerCooldown)
+	}
+}
+
+// Call runs fn if the circuit allows it and records the outcome.
+func (b *Breaker) Call(transient func(error) bool, fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	err := fn()
+	b.Record(err != nil && transient(err))
+	return err
+}
+
+// Breakers keeps a Breaker per upstream endpoint, so one failing provider
+// or backend does not pause calls to the others.
+type Breakers struct {
+	// Name prefixes each breaker's name in logs.
+	Name string
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// For returns the breaker of the endpoint at rawURL, creating it on first
+// use. Query strings and user info are dropped so credentials never end up
+// in a breaker's name.
+func (s *Breakers) For(rawURL string) *Breaker {
+	key := Endpoint(rawURL)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.breakers[key]
+	if !ok {
+		if s.breakers == nil {
+			s.breakers = map[string]*Breaker{}
+		}
+		b = &Breaker{Name: s.Name + " " + key, Endpoint: key}
+		s.breakers[key] = b
+	}
+	return b
+}
+
+// All returns every breaker created so far, sorted by endpoint.
+func (s *Breakers) All() []*Breaker {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	all := make([]*Breaker, 0, len(s.breakers))
+	for _, b := range s.breakers {
+		all = append(all, b)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Endpoint < all[j].Endpoint })
+	return all
+}
+
+// Endpoint is rawURL without its query, fragment and user info.
+func Endpoint(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.User, u.RawQuery, u.Fragment = nil, "", ""
+	return u.String()
+}
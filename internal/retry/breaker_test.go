@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerOpen(t *testing.T) {
+	defer func(threshold int, cooldown time.Duration) {
+		CircuitBreakerThreshold, CircuitBreakerCooldown = threshold, cooldown
+	}(CircuitBreakerThreshold, CircuitBreakerCooldown)
+	CircuitBreakerThreshold = 2
+	CircuitBreakerCooldown = 20 * time.Millisecond
+
+	b := &Breaker{Name: "test"}
+	steps := []struct {
+		name     string
+		do       func()
+		wantOpen bool
+	}{
+		{"closed", func() {}, false},
+		{"one failure", func() { b.Record(true) }, false},
+		{"threshold reached", func() { b.Record(true) }, true},
+		{"cooldown passed", func() { time.Sleep(2 * CircuitBreakerCooldown) }, false},
+		{"trial call in flight", func() {
+			if err := b.Allow(); err != nil {
+				t.Fatalf("Allow after the cooldown: %v", err)
+			}
+		}, true},
+		{"trial call failed", func() { b.Record(true) }, true},
+		{"second cooldown passed", func() { time.Sleep(2 * CircuitBreakerCooldown) }, false},
+		{"trial call succeeded", func() {
+			b.Allow()
+			b.Record(false)
+		}, false},
+	}
+	for _, s := range steps {
+		s.do()
+		if got := b.Open(); got != s.wantOpen {
+			t.Errorf("%s: Open() = %v, want %v", s.name, got, s.wantOpen)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// ParseRetryAfter understands both forms of the Retry-After header:
+// delay-seconds and an HTTP date.
+func ParseRetryAfter(h string) (time.Duration, bool) {
+	h = strings.TrimSpace(h)
+	if h == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// Cooldown is a process-wide pause shared by every caller of the same
+// upstream, so concurrent sends back off together after a 429.
+type Cooldown struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+func (c *Cooldown) Extend(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t := time.Now().Add(d); t.After(c.until) {
+		c.until = t
+	}
+}
+
+// Wait blocks until the cooldown has elapsed, or returns ctx's error if
+// the context ends first.
+func (c *Cooldown) Wait(ctx context.Context, name string) error {
+	c.mu.Lock()
+	d := time.Until(c.until)
+	c.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	runctx.Logf(ctx, "⏸️ %s is cooling down, waiting %s", name, d.Round(time.Second))
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,51 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter spaces calls evenly so no more than a set number start per
+// minute. A nil Limiter never waits.
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// NewLimiter allows perMinute calls a minute, or returns nil for no limit
+// when perMinute is not positive.
+func NewLimiter(perMinute int) *Limiter {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &Limiter{interval: time.Minute / time.Duration(perMinute)}
+}
+
+// Wait blocks until the next call may start or ctx is done.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	start := time.Now()
+	if l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
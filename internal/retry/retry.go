@@ -0,0 +1,49 @@
+// Package retry holds the retry loop, circuit breakers and cooldowns
+// shared by the LLM and backend calls.
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// retryAfterError is implemented by errors that carry a server-requested
+// wait, which then replaces the computed backoff.
+type retryAfterError interface {
+	RetryAfter() time.Duration
+}
+
+// Do runs fn, retrying up to attempts more times while retryable
+// reports the error as transient. The wait starts around backoff, with
+// ±50% jitter so concurrent callers spread out, and doubles after every
+// attempt.
+func Do(ctx context.Context, label string, attempts int, backoff time.Duration, retryable func(error) bool, fn func() error) error {
+	err := fn()
+	for i := 1; i <= attempts && err != nil && retryable(err); i++ {
+		wait := jitter(backoff)
+		var ra retryAfterError
+		if errors.As(err, &ra) && ra.RetryAfter() > 0 {
+			wait = ra.RetryAfter()
+		}
+		runctx.Logf(ctx, "🔁 %s: %v, retrying in %s (%d/%d)", label, err, wait.Round(time.Millisecond), i, attempts)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		err = fn()
+	}
+	return err
+}
+
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)))
+}
@@ -0,0 +1,116 @@
+package runctx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	Logger = slog.New(NewPlainHandler(os.Stderr, slog.LevelInfo))
+)
+
+// LogBridge turns lines written through the log package into records.
+type LogBridge struct{}
+
+func (LogBridge) Write(p []byte) (int, error) {
+	msg := strings.TrimSuffix(string(p), "\n")
+	Logger.Log(context.Background(), LevelOf(msg), msg)
+	return len(p), nil
+}
+
+// LevelOf infers a level from the emoji that starts every message. Dumps
+// of model output are debug, failures are errors and warnings warn.
+func LevelOf(msg string) slog.Level {
+	switch {
+	case strings.HasPrefix(msg, "❌"), strings.HasPrefix(msg, "💥"):
+		return slog.LevelError
+	case strings.HasPrefix(msg, "⚠️"):
+		return slog.LevelWarn
+	case strings.HasPrefix(msg, "📥"), strings.HasPrefix(msg, "🧼"), strings.HasPrefix(msg, "🛠️"):
+		return slog.LevelDebug
+	}
+	return slog.LevelInfo
+}
+
+// Logf logs like log.Printf, tagged with the run's ID and job so every
+// line of a run can be correlated.
+func Logf(ctx context.Context, format string, args ...interface{}) {
+	logRun(ctx, fmt.Sprintf(format, args...))
+}
+
+// Logln logs like log.Println, tagged like Logf.
+func Logln(ctx context.Context, args ...interface{}) {
+	logRun(ctx, strings.TrimSuffix(fmt.Sprintln(args...), "\n"))
+}
+
+func logRun(ctx context.Context, msg string) {
+	var attrs []slog.Attr
+	if id := RunID(ctx); id != "" {
+		attrs = append(attrs, slog.String("run_id", id))
+	}
+	if job := JobName(ctx); job != "" {
+		attrs = append(attrs, slog.String("job", job))
+	}
+	Logger.LogAttrs(ctx, LevelOf(msg), msg, attrs...)
+}
+
+// plainHandler writes records in the classic log format, with the run ID
+// in brackets and any other attributes appended as key=value.
+type plainHandler struct {
+	mu    *sync.Mutex
+	w     io.Writer
+	level slog.Leveler
+	attrs []slog.Attr
+}
+
+func NewPlainHandler(w io.Writer, level slog.Leveler) *plainHandler {
+	return &plainHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *plainHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= h.level.Level()
+}
+
+func (h *plainHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format("2006/01/02 15:04:05 "))
+
+	var extra []string
+	add := func(a slog.Attr) bool {
+		if a.Key == "run_id" {
+			b.WriteString("[" + a.Value.String() + "] ")
+		} else {
+			extra = append(extra, a.Key+"="+a.Value.String())
+		}
+		return true
+	}
+	for _, a := range h.attrs {
+		add(a)
+	}
+	r.Attrs(add)
+
+	b.WriteString(r.Message)
+	for _, e := range extra {
+		b.WriteString(" " + e)
+	}
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *plainHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.attrs = append(append([]slog.Attr{}, h.attrs...), attrs...)
+	return &c
+}
+
+// WithGroup is not needed by this program; groups are flattened.
+func (h *plainHandler) WithGroup(string) slog.Handler { return h }
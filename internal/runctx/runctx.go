@@ -0,0 +1,56 @@
+// Package runctx carries the run ID and job name through a run and logs
+// with them.
+package runctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+type jobNameKey struct{}
+
+// WithJobName tags the run with the name of the job it belongs to.
+func WithJobName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, jobNameKey{}, name)
+}
+
+// JobName returns the run's job name, or "" for the default job.
+func JobName(ctx context.Context) string {
+	name, _ := ctx.Value(jobNameKey{}).(string)
+	return name
+}
+
+type runIDKey struct{}
+
+var RequestIDHeader = "X-Request-ID"
+
+// NewRunID returns a random ID for a new run.
+func NewRunID() string { return RandomHex(8) }
+
+// WithRunID tags the context with a run ID for logs and requests.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, runIDKey{}, id)
+}
+
+// RunID returns the run's ID, or "".
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey{}).(string)
+	return id
+}
+
+// SetRunIDHeader tags an outgoing request with the run ID so it can be
+// traced through the provider and backend logs.
+func SetRunIDHeader(ctx context.Context, req *http.Request) {
+	if id := RunID(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// RandomHex returns n random bytes, hex-encoded.
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
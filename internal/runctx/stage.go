@@ -0,0 +1,51 @@
+package runctx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WithStageTimeout bounds a single stage. The run deadline on the parent
+// context still applies, whichever is sooner.
+func WithStageTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// StageError names the stage whose context expired, so a timeout doesn't
+// surface as a bare "context deadline exceeded". parent is the context the
+// stage context was derived from.
+func StageError(parent, stage context.Context, name string, limit time.Duration, err error) error {
+	switch {
+	case stage.Err() == nil:
+		return err
+	case errors.Is(parent.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s stage hit the run deadline: %w", name, err)
+	case errors.Is(stage.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%s stage timed out after %s: %w", name, limit, err)
+	}
+	return fmt.Errorf("%s stage cancelled: %w", name, err)
+}
+
+// WithinTimeout runs a CPU-bound step, giving up after d. It is a safety
+// net against pathological inputs rather than a cancellation mechanism:
+// the step keeps running in the background if it overruns.
+func WithinTimeout(d time.Duration, stage string, fn func() error) error {
+	if d <= 0 {
+		return fn()
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- fn() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(d):
+		return fmt.Errorf("%s stage timed out after %s", stage, d)
+	}
+}
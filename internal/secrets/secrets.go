@@ -0,0 +1,278 @@
+// Package secrets resolves configuration values that reference a secret
+// store instead of holding the secret: vault://, awssm:// and file://
+// (e.g. Docker secrets under /run/secrets).
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/sigv4"
+)
+
+var (
+	VaultAddr      = "http://127.0.0.1:8200"
+	VaultToken     string
+	VaultNamespace string
+
+	AWSRegion = "us-east-1"
+	AWS       sigv4.Credentials
+	// AWSEndpoint replaces the regional Secrets Manager endpoint, e.g. for
+	// LocalStack.
+	AWSEndpoint string
+
+	Timeout = 10 * time.Second
+	client  = &http.Client{}
+)
+
+// IsRef reports whether v names a secret rather than holding a value.
+func IsRef(v string) bool {
+	for _, prefix := range []string{"vault://", "awssm://", "file://"} {
+		if strings.HasPrefix(v, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	mu       sync.Mutex
+	resolved = map[string]string{}
+	tracked  = map[string]string{}
+)
+
+// Resolve returns the secret ref points to, fetching it once and caching it
+// until the next refresh. key is the setting that referenced it, tracked
+// so Watch can report rotations.
+func Resolve(ctx context.Context, key, ref string) (string, error) {
+	mu.Lock()
+	v, ok := resolved[ref]
+	mu.Unlock()
+	if ok {
+		track(key, ref)
+		return v, nil
+	}
+
+	v, err := fetch(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	mu.Lock()
+	resolved[ref] = v
+	mu.Unlock()
+	track(key, ref)
+	return v, nil
+}
+
+func track(key, ref string) {
+	mu.Lock()
+	tracked[key] = ref
+	mu.Unlock()
+}
+
+// Tracked reports whether any setting came from a secret store.
+func Tracked() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(tracked) > 0
+}
+
+// Watch refetches every tracked secret each interval until ctx is done and
+// calls onChange with the settings whose secret was rotated. A failed
+// refresh keeps the old value.
+func Watch(ctx context.Context, interval time.Duration, onChange func(changed map[string]string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if changed := refresh(ctx); len(changed) > 0 {
+			onChange(changed)
+		}
+	}
+}
+
+// refresh refetches each referenced secret once and returns the settings
+// whose value changed.
+func refresh(ctx context.Context) map[string]string {
+	mu.Lock()
+	keys := make(map[string]string, len(tracked))
+	for k, ref := range tracked {
+		keys[k] = ref
+	}
+	mu.Unlock()
+
+	fresh := map[string]string{}
+	changed := map[string]string{}
+	for key, ref := range keys {
+		v, ok := fresh[ref]
+		if !ok {
+			var err error
+			if v, err = fetch(ctx, ref); err != nil {
+				log.Printf("⚠️ Could not refresh secret for %s: %v", key, err)
+				continue
+			}
+			fresh[ref] = v
+		}
+		mu.Lock()
+		old := resolved[ref]
+		mu.Unlock()
+		if v != old {
+			changed[key] = v
+		}
+	}
+	mu.Lock()
+	for ref, v := range fresh {
+		resolved[ref] = v
+	}
+	mu.Unlock()
+	return changed
+}
+
+func fetch(ctx context.Context, ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	switch {
+	case strings.HasPrefix(ref, "file://"):
+		data, err := os.ReadFile(strings.TrimPrefix(ref, "file://"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(ref, "vault://"):
+		path, field := splitField(strings.TrimPrefix(ref, "vault://"))
+		return fetchVault(ctx, path, field)
+	case strings.HasPrefix(ref, "awssm://"):
+		id, field := splitField(strings.TrimPrefix(ref, "awssm://"))
+		return fetchAWS(ctx, id, field)
+	}
+	return "", fmt.Errorf("unsupported secret reference %q", ref)
+}
+
+// splitField separates the "#field" suffix that picks one key of a
+// structured secret.
+func splitField(s string) (string, string) {
+	if i := strings.LastIndex(s, "#"); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+	return s, ""
+}
+
+// fetchVault reads path from the Vault HTTP API, e.g.
+// vault://secret/data/autopost#groq_api_key for a KV v2 mount named
+// "secret". The field defaults to "value".
+func fetchVault(ctx context.Context, path, field string) (string, error) {
+	if VaultToken == "" {
+		return "", fmt.Errorf("VAULT_TOKEN not set")
+	}
+	if field == "" {
+		field = "value"
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", strings.TrimRight(VaultAddr, "/")+"/v1/"+strings.TrimLeft(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", VaultToken)
+	if VaultNamespace != "" {
+		req.Header.Set("X-Vault-Namespace", VaultNamespace)
+	}
+	body, err := do(req, "Vault")
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("could not parse Vault response: %w", err)
+	}
+	// KV v2 nests the secret under data.data; KV v1 returns it as data.
+	data := result.Data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	return stringField(data, field, "Vault secret "+path)
+}
+
+// fetchAWS calls Secrets Manager GetSecretValue for id, a name or ARN. With
+// a field the secret string is parsed as JSON and that key returned.
+func fetchAWS(ctx context.Context, id, field string) (string, error) {
+	if AWS.AccessKeyID == "" || AWS.SecretAccessKey == "" {
+		return "", fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	region := AWSRegion
+	// arn:aws:secretsmanager:<region>:<account>:secret:<name>
+	if parts := strings.Split(id, ":"); len(parts) > 3 && parts[0] == "arn" {
+		region = parts[3]
+	}
+	endpoint := AWSEndpoint
+	if endpoint == "" {
+		endpoint = "https://secretsmanager." + region + ".amazonaws.com/"
+	}
+
+	payload, _ := json.Marshal(map[string]string{"SecretId": id})
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	sigv4.Sign(req, payload, "secretsmanager", region, AWS, time.Now())
+	body, err := do(req, "Secrets Manager")
+	if err != nil {
+		return "", err
+	}
+
+	var result struct {
+		SecretString string `json:"SecretString"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("could not parse Secrets Manager response: %w", err)
+	}
+	if field == "" {
+		return result.SecretString, nil
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(result.SecretString), &data); err != nil {
+		return "", fmt.Errorf("secret %s is not a JSON object, so #%s cannot be read: %w", id, field, err)
+	}
+	return stringField(data, field, "secret "+id)
+}
+
+func stringField(data map[string]interface{}, field, what string) (string, error) {
+	v, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("%s has no field %q", what, field)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return fmt.Sprint(v), nil
+}
+
+func do(req *http.Request, name string) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned %s: %s", name, resp.Status, body)
+	}
+	return body, nil
+}
@@ -0,0 +1,105 @@
+// Package state persists small state files in the background.
+package state
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// File batches writes of a small on-disk state file (dedup history
+// and the like). Callers mark it dirty after changing the in-memory state;
+// a background flusher persists it every FlushInterval, and
+// FlushAll writes everything out on shutdown.
+type File struct {
+	mu       sync.Mutex
+	path     string
+	dirty    bool
+	snapshot func() ([]byte, error)
+}
+
+var (
+	FlushInterval = 5 * time.Second
+
+	stateMu    sync.Mutex
+	stateFiles []*File
+)
+
+// Register tracks the state file at path, written from snapshot.
+func Register(path string, snapshot func() ([]byte, error)) *File {
+	f := &File{path: path, snapshot: snapshot}
+	stateMu.Lock()
+	stateFiles = append(stateFiles, f)
+	stateMu.Unlock()
+	return f
+}
+
+func (f *File) MarkDirty() {
+	f.mu.Lock()
+	f.dirty = true
+	f.mu.Unlock()
+
+	if FlushInterval <= 0 {
+		f.Flush()
+	}
+}
+
+// Flush writes the file if it changed, via a temp file and rename so a
+// crash mid-write never leaves it truncated.
+func (f *File) Flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.dirty {
+		return nil
+	}
+
+	data, err := f.snapshot()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+	f.dirty = false
+	return nil
+}
+
+// FlushAll writes every dirty state file.
+func FlushAll() {
+	stateMu.Lock()
+	files := append([]*File(nil), stateFiles...)
+	stateMu.Unlock()
+
+	for _, f := range files {
+		if err := f.Flush(); err != nil {
+			log.Printf("⚠️ Failed to write %s: %v", f.path, err)
+		}
+	}
+}
+
+// StartFlusher flushes the state files every FlushInterval.
+func StartFlusher() {
+	if FlushInterval <= 0 {
+		return
+	}
+	go func() {
+		for range time.Tick(FlushInterval) {
+			FlushAll()
+		}
+	}()
+}
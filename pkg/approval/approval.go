@@ -0,0 +1,233 @@
+// Package approval holds generated payloads for a human to approve before
+// they are published. Items wait in a local store, are announced on the
+// configured review channel and expire when nobody decides in time.
+package approval
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/output"
+)
+
+var (
+	// Enabled holds every payload for review instead of sending it.
+	Enabled bool
+	// BaseURL is where this worker's HTTP server is reachable, for the
+	// approve and reject links. Without it reviewers use the admin API.
+	BaseURL string
+	// Expire is how long an item waits for a decision.
+	Expire = 72 * time.Hour
+	// Retention is how long decided and expired items stay listed.
+	Retention = 7 * 24 * time.Hour
+)
+
+// Item statuses. An approved item whose delivery failed is "failed" and can
+// be approved again.
+const (
+	Pending  = "pending"
+	Approved = "approved"
+	Rejected = "rejected"
+	Expired  = "expired"
+	Failed   = "failed"
+)
+
+// Item is one payload awaiting or past review.
+type Item struct {
+	ID      string          `json:"id"`
+	RunID   string          `json:"runId,omitempty"`
+	Job     string          `json:"job,omitempty"`
+	Title   string          `json:"title"`
+	Status  string          `json:"status"`
+	Error   string          `json:"error,omitempty"`
+	Created time.Time       `json:"created"`
+	Expires time.Time       `json:"expires"`
+	Decided time.Time       `json:"decided,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+	Token   string          `json:"token,omitempty"`
+	// Entry is the generated prompt or document the generator records as
+	// sent once the item is approved and delivered.
+	Entry json.RawMessage `json:"entry,omitempty"`
+	// Sinks are the outputs a failed delivery still has to reach; empty
+	// means all of them.
+	Sinks []string `json:"sinks,omitempty"`
+}
+
+// Open reports whether the item can still be approved.
+func (it Item) Open() bool { return it.Status == Pending || it.Status == Failed }
+
+// CheckToken reports whether token is the item's link token.
+func (it Item) CheckToken(token string) bool {
+	return it.Token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(it.Token)) == 1
+}
+
+// Store keeps the items, persisted to a state file.
+type Store struct {
+	mu    sync.Mutex
+	items map[string]*Item
+	file  *state.File
+}
+
+var Queue = &Store{items: map[string]*Item{}}
+
+// Load reads the approval store at path.
+func Load(path string) *Store {
+	s := &Store{items: map[string]*Item{}}
+	s.file = state.Register(path, func() ([]byte, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return json.MarshalIndent(s.items, "", "  ")
+	})
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &s.items); err != nil {
+			log.Println("⚠️ Could not parse approval store, starting empty:", err)
+			s.items = map[string]*Item{}
+		}
+	} else if !os.IsNotExist(err) {
+		log.Println("⚠️ Could not read approval store:", err)
+	}
+	return s
+}
+
+// Submit stores payload, and the entry to record once it is sent, as a
+// pending item and announces it to reviewers. A failed announcement is only
+// logged; the item can still be decided through the admin API.
+func (s *Store) Submit(ctx context.Context, payload, entry []byte) Item {
+	now := time.Now()
+	it := &Item{
+		ID:      runctx.RandomHex(8),
+		RunID:   runctx.RunID(ctx),
+		Job:     runctx.JobName(ctx),
+		Title:   payloadTitle(payload),
+		Status:  Pending,
+		Created: now,
+		Expires: now.Add(Expire),
+		Payload: payload,
+		Entry:   entry,
+		Token:   runctx.RandomHex(16),
+	}
+	s.mu.Lock()
+	s.items[it.ID] = it
+	s.mu.Unlock()
+	s.markDirty()
+
+	runctx.Logf(ctx, "📝 Holding %q for approval as %s, expires %s", it.Title, it.ID, it.Expires.Format(time.RFC3339))
+	if err := output.SendReview(ctx, review(*it, payload)); err != nil {
+		runctx.Logln(ctx, "⚠️ Could not announce the approval request:", err)
+	}
+	return *it
+}
+
+func review(it Item, payload []byte) output.Review {
+	r := output.Review{ID: it.ID, Job: it.Job, Title: it.Title, Expires: it.Expires, Payload: payload}
+	var fields struct {
+		Description string `json:"description"`
+	}
+	json.Unmarshal(payload, &fields)
+	r.Description = fields.Description
+	if BaseURL != "" {
+		link := strings.TrimRight(BaseURL, "/") + "/approvals/" + it.ID
+		r.ApproveURL = link + "/approve?token=" + it.Token
+		r.RejectURL = link + "/reject?token=" + it.Token
+	}
+	return r
+}
+
+// Get returns the item with id.
+func (s *Store) Get(id string) (Item, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[id]
+	if !ok {
+		return Item{}, false
+	}
+	return *it, true
+}
+
+// List returns the items with status, or all of them, oldest first.
+func (s *Store) List(status string) []Item {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	items := []Item{}
+	for _, it := range s.items {
+		if status == "" || it.Status == status {
+			items = append(items, *it)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Created.Before(items[j].Created) })
+	return items
+}
+
+// Settle records the decision on an item; errMsg is the delivery error of
+// an approval that failed and sinks the outputs it did not reach.
+func (s *Store) Settle(id, status, errMsg string, sinks []string) (Item, bool) {
+	s.mu.Lock()
+	it, ok := s.items[id]
+	if ok {
+		it.Status, it.Error, it.Sinks, it.Decided = status, errMsg, sinks, time.Now()
+		if status != Failed {
+			// Only open items need their link to keep working.
+			it.Token = ""
+		}
+	}
+	s.mu.Unlock()
+	if !ok {
+		return Item{}, false
+	}
+	s.markDirty()
+	return *it, true
+}
+
+// Sweep expires pending items past their deadline and drops decided ones
+// older than Retention. It returns the items that expired.
+func (s *Store) Sweep(now time.Time) []Item {
+	var expired []Item
+	changed := false
+	s.mu.Lock()
+	for id, it := range s.items {
+		switch {
+		case it.Status == Pending && now.After(it.Expires):
+			it.Status, it.Decided, it.Token = Expired, now, ""
+			expired = append(expired, *it)
+			changed = true
+		case it.Status != Pending && Retention > 0 && now.Sub(it.Decided) > Retention:
+			delete(s.items, id)
+			changed = true
+		}
+	}
+	s.mu.Unlock()
+	if changed {
+		s.markDirty()
+	}
+	return expired
+}
+
+func (s *Store) markDirty() {
+	if s.file != nil {
+		s.file.MarkDirty()
+	}
+}
+
+// payloadTitle names an item after its payload's title, subject or
+// headline.
+func payloadTitle(payload []byte) string {
+	var fields map[string]interface{}
+	json.Unmarshal(payload, &fields)
+	for _, key := range []string{"title", "subject", "headline", "name"} {
+		if s, ok := fields[key].(string); ok && strings.TrimSpace(s) != "" {
+			return strings.TrimSpace(s)
+		}
+	}
+	return "untitled"
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	archiveMu sync.Mutex
+
+	StoreRaw       bool
+	RawArchiveFile string
+	BackupFile     = "prompts.jsonl"
+
+	// ArchiveMaxSize rotates an archive once it reaches this many bytes;
+	// zero disables rotation. ArchiveKeep rotated files are kept.
+	ArchiveMaxSize int64
+	ArchiveKeep    = 5
+)
+
+// appendJSONL appends v as a single JSON line to path, creating the file
+// if needed.
+func appendJSONL(path string, v interface{}) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	archiveMu.Lock()
+	defer archiveMu.Unlock()
+
+	if err := rotateArchive(path, int64(len(line))+1); err != nil {
+		log.Println("⚠️ Could not rotate archive", path+":", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// rotateArchive moves path aside to a timestamped name when appending n
+// more bytes would take it past ArchiveMaxSize, then prunes old rotations.
+func rotateArchive(path string, n int64) error {
+	if ArchiveMaxSize <= 0 {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() == 0 || info.Size()+n <= ArchiveMaxSize {
+		return nil
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	rotated := base + "-" + time.Now().UTC().Format(archiveStamp) + ext
+	if err := os.Rename(path, rotated); err != nil {
+		return err
+	}
+	log.Println("🗄️ Rotated archive to", rotated)
+
+	old, err := rotatedArchives(base, ext)
+	if err != nil || len(old) <= ArchiveKeep {
+		return err
+	}
+	// The timestamp format sorts chronologically.
+	sort.Strings(old)
+	for _, p := range old[:len(old)-ArchiveKeep] {
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// archiveStamp is the UTC timestamp in rotated archive names.
+const archiveStamp = "20060102T150405.000"
+
+// rotatedArchives lists the files rotateArchive made from base+ext, leaving
+// out anything else that happens to share the prefix, e.g. a
+// "prompts-backup.jsonl" next to "prompts.jsonl".
+func rotatedArchives(base, ext string) ([]string, error) {
+	matches, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return nil, err
+	}
+	var rotated []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, base+"-"), ext)
+		if _, err := time.Parse(archiveStamp, stamp); err == nil {
+			rotated = append(rotated, m)
+		}
+	}
+	return rotated, nil
+}
+
+// backupPrompt appends the prompt to BACKUP_FILE before it is sent, so it
+// survives a backend outage. Failing to write only logs.
+func backupPrompt(ctx context.Context, p PromptResponse) {
+	entry := struct {
+		Timestamp time.Time `json:"timestamp"`
+		RunID     string    `json:"runId,omitempty"`
+		Status    string    `json:"status"`
+		PromptResponse
+	}{time.Now(), runctx.RunID(ctx), "pending", p}
+	if err := appendJSONL(BackupFile, entry); err != nil {
+		runctx.Logln(ctx, "⚠️ Failed to write prompt backup:", err)
+	}
+}
+
+// backupDocument is backupPrompt for a schema document.
+func backupDocument(ctx context.Context, doc map[string]interface{}) {
+	entry := map[string]interface{}{"timestamp": time.Now(), "status": "pending"}
+	if runID := runctx.RunID(ctx); runID != "" {
+		entry["runId"] = runID
+	}
+	for k, v := range doc {
+		if _, taken := entry[k]; !taken {
+			entry[k] = v
+		}
+	}
+	if err := appendJSONL(BackupFile, entry); err != nil {
+		runctx.Logln(ctx, "⚠️ Failed to write document backup:", err)
+	}
+}
+
+// storeRaw keeps the unextracted model output when STORE_RAW is set: in
+// RAW_ARCHIVE_FILE keyed by run ID if configured, otherwise as "_raw" in
+// the payload itself.
+func storeRaw(ctx context.Context, payload map[string]interface{}, sector, raw string) {
+	if !StoreRaw || raw == "" {
+		return
+	}
+	if RawArchiveFile == "" {
+		payload["_raw"] = raw
+		return
+	}
+
+	entry := map[string]interface{}{
+		"runId":     runctx.RunID(ctx),
+		"timestamp": time.Now(),
+		"sector":    sector,
+		"raw":       raw,
+	}
+	if err := appendJSONL(RawArchiveFile, entry); err != nil {
+		runctx.Logln(ctx, "⚠️ Failed to archive raw response:", err)
+	}
+}
@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRotatedArchives(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{
+		"prompts.jsonl",
+		"prompts-20260101T090000.000.jsonl",
+		"prompts-20260102T090000.123.jsonl",
+		"prompts-backup.jsonl",
+		"prompts-20260103.jsonl",
+		"prompts-20260101T090000.000.json",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := rotatedArchives(filepath.Join(dir, "prompts"), ".jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(dir, "prompts-20260101T090000.000.jsonl"),
+		filepath.Join(dir, "prompts-20260102T090000.123.jsonl"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rotatedArchives() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,141 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ContentType is a kind of document jobs can generate, defined by files
+// rather than code: <name>.schema.json in CONTENT_TYPES_DIR describes and
+// validates the output, and an optional <name>.tmpl is its generation
+// prompt. The name "prompt" is reserved for the built-in AI prompt
+// pipeline.
+type ContentType struct {
+	Name   string
+	Schema *Schema
+
+	template       *template.Template
+	templateSource string
+}
+
+var (
+	ContentTypes = map[string]*ContentType{}
+
+	// DefaultContentType is used by jobs that do not name one, or nil for
+	// AI prompts.
+	DefaultContentType *ContentType
+)
+
+// PromptContentType names the built-in PromptResponse pipeline.
+const PromptContentType = "prompt"
+
+// LoadContentTypes reads every content type in dir. A missing directory
+// defines none.
+func LoadContentTypes(dir string) (map[string]*ContentType, error) {
+	types := map[string]*ContentType{}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.schema.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var problems []error
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".schema.json")
+		if name == PromptContentType {
+			problems = append(problems, fmt.Errorf("%s: %q is reserved for the built-in pipeline", path, name))
+			continue
+		}
+		ct := &ContentType{Name: name}
+		if ct.Schema, err = LoadOutputSchema(path); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", path, err))
+			continue
+		}
+
+		tmplPath := filepath.Join(dir, name+".tmpl")
+		text, err := os.ReadFile(tmplPath)
+		switch {
+		case os.IsNotExist(err):
+		case err != nil:
+			problems = append(problems, err)
+			continue
+		default:
+			if ct.template, err = template.New(filepath.Base(tmplPath)).Parse(string(text)); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", tmplPath, err))
+				continue
+			}
+			ct.templateSource = string(text)
+		}
+		types[name] = ct
+	}
+	return types, JoinProblems(problems)
+}
+
+// FindContentType returns the content type called name, nil for
+// "prompt", or an error naming the ones that exist.
+func FindContentType(name string) (*ContentType, error) {
+	if name == PromptContentType {
+		return nil, nil
+	}
+	if ct, ok := ContentTypes[name]; ok {
+		return ct, nil
+	}
+	names := []string{PromptContentType}
+	for n := range ContentTypes {
+		names = append(names, n)
+	}
+	sort.Strings(names[1:])
+	return nil, fmt.Errorf("unknown content type %q (want %s)", name, strings.Join(names, ", "))
+}
+
+// label is how the generic template refers to the content: the schema's
+// title, or the name with dashes as spaces.
+func (ct *ContentType) label() string {
+	if ct.Schema.Title != "" {
+		return ct.Schema.Title
+	}
+	return strings.ReplaceAll(ct.Name, "-", " ")
+}
+
+// contentTypeFrom returns the run's content type, or nil for AI prompts.
+func contentTypeFrom(ctx context.Context) *ContentType {
+	if j := JobFrom(ctx); j != nil && j.ContentType != "" {
+		return j.content
+	}
+	return DefaultContentType
+}
+
+// SchemaFrom returns the schema the run's output must follow: its content
+// type's, or OUTPUT_SCHEMA_PATH's. Nil means the PromptResponse pipeline.
+func SchemaFrom(ctx context.Context) *Schema {
+	if ct := contentTypeFrom(ctx); ct != nil {
+		return ct.Schema
+	}
+	return OutputSchema
+}
+
+const documentTemplate = `Generate one {{.ContentType}} for professionals in the {{.Sector}} sector.
+{{- if .ContentDescription}}
+
+{{.ContentDescription}}
+{{- end}}
+
+Your task is to:
+- Make it practical, specific and high-quality for the selected sector
+{{- if .Tone}}
+- Write it in a {{.Tone}} tone
+{{- end}}
+{{- if .Language}}
+- Write every value in {{.Language}}, keeping the JSON keys in English
+{{- end}}
+- Wrap your response in a clean JSON object with these keys:
+{{.Keys}}
+
+Output your response ONLY as a JSON object, without any extra commentary or Markdown.`
+
+var parsedDocumentTemplate = template.Must(template.New("document").Parse(documentTemplate))
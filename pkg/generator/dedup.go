@@ -0,0 +1,325 @@
+package generator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+)
+
+var (
+	DedupRegenAttempts = 1
+)
+
+var (
+	DedupWindow    = 30
+	DedupThreshold = 0.6
+
+	// DedupHashHistory is how many sent prompts are kept for exact
+	// duplicate detection, well beyond the similarity window.
+	DedupHashHistory = 1000
+)
+
+var ErrDuplicate = errors.New("generated prompt is a duplicate of a recent one")
+
+type SeenEntry struct {
+	Title     string    `json:"title"`
+	Prompt    string    `json:"prompt"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// seenFile is the on-disk layout of the dedup store. Older stores are a
+// bare array of entries, or also list hashes of prompts no longer kept,
+// which are dropped since there is no entry to report them against.
+type seenFile struct {
+	Entries []SeenEntry `json:"entries"`
+	Hashes  []string    `json:"hashes,omitempty"`
+}
+
+// SeenStore remembers the last DedupHashHistory sent prompts. The most
+// recent window of them are checked for near-identical generations before
+// they reach the backend, all of them for exact reposts.
+type SeenStore struct {
+	mu      sync.Mutex
+	window  int
+	entries []SeenEntry
+	hashSet map[string]int
+	file    *state.File
+}
+
+// LoadSeenStore reads the dedup history at path, keeping the last window
+// prompts for the similarity check.
+func LoadSeenStore(path string, window int) *SeenStore {
+	s := &SeenStore{window: window, hashSet: map[string]int{}}
+	s.file = state.Register(path, s.marshal)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("⚠️ Could not read dedup store:", err)
+		}
+		return s
+	}
+
+	var stored seenFile
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &stored.Entries)
+	} else {
+		err = json.Unmarshal(data, &stored)
+	}
+	if err != nil {
+		log.Println("⚠️ Could not parse dedup store, starting empty:", err)
+		return s
+	}
+	s.entries = stored.Entries
+	for _, e := range s.entries {
+		s.hashSet[promptHash(e.Prompt)]++
+	}
+	return s
+}
+
+// promptHash identifies a prompt regardless of case and whitespace.
+func promptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(normalizeText(prompt)))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindSimilar returns the most similar recent entry if it crosses the
+// duplicate threshold. A prompt sent before is always a duplicate, even
+// once it has left the similarity window.
+func (s *SeenStore) FindSimilar(p PromptResponse) (SeenEntry, float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hashSet[promptHash(p.Prompt)] > 0 {
+		for i := len(s.entries) - 1; i >= 0; i-- {
+			if normalizeText(s.entries[i].Prompt) == normalizeText(p.Prompt) {
+				return s.entries[i], 1, true
+			}
+		}
+	}
+
+	var best SeenEntry
+	bestScore := 0.0
+	for _, e := range s.recent() {
+		if score := Similarity(p.Title, p.Prompt, e.Title, e.Prompt); score > bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best, bestScore, bestScore >= DedupThreshold
+}
+
+// recent returns the entries in the similarity window.
+func (s *SeenStore) recent() []SeenEntry {
+	if len(s.entries) > s.window {
+		return s.entries[len(s.entries)-s.window:]
+	}
+	return s.entries
+}
+
+func (s *SeenStore) Add(p PromptResponse) error {
+	s.mu.Lock()
+	s.entries = append(s.entries, SeenEntry{Title: p.Title, Prompt: p.Prompt, CreatedAt: time.Now()})
+	s.hashSet[promptHash(p.Prompt)]++
+	if keep := max(s.window, DedupHashHistory); len(s.entries) > keep {
+		// Hashes leave the set together with their entries.
+		for _, e := range s.entries[:len(s.entries)-keep] {
+			h := promptHash(e.Prompt)
+			if s.hashSet[h] <= 1 {
+				delete(s.hashSet, h)
+			} else {
+				s.hashSet[h]--
+			}
+		}
+		s.entries = append([]SeenEntry(nil), s.entries[len(s.entries)-keep:]...)
+	}
+	s.mu.Unlock()
+
+	s.file.MarkDirty()
+	return nil
+}
+
+// RecentTitles returns up to n of the most recently sent titles, newest
+// first.
+func (s *SeenStore) RecentTitles(n int) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var titles []string
+	recent := s.recent()
+	for i := len(recent) - 1; i >= 0 && len(titles) < n; i-- {
+		if t := strings.TrimSpace(recent[i].Title); t != "" {
+			titles = append(titles, t)
+		}
+	}
+	return titles
+}
+
+// UniqueSlug derives a URL slug from the title, appending a short hash of
+// the prompt text when a recently sent prompt already uses it.
+func (s *SeenStore) UniqueSlug(p PromptResponse) string {
+	slug := slugify(p.Title)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if slugify(e.Title) == slug {
+			sum := sha256.Sum256([]byte(p.Prompt))
+			hash := hex.EncodeToString(sum[:])[:6]
+			if slug == "" {
+				return hash
+			}
+			return slug + "-" + hash
+		}
+	}
+	return slug
+}
+
+func (s *SeenStore) marshal() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return json.MarshalIndent(seenFile{Entries: s.entries}, "", "  ")
+}
+
+// Similarity scores how alike two prompts are, from 0 to 1.
+func Similarity(titleA, promptA, titleB, promptB string) float64 {
+	if normalizeText(titleA) == normalizeText(titleB) {
+		return 1
+	}
+	return jaccard(tokenize(titleA+" "+promptA), tokenize(titleB+" "+promptB))
+}
+
+func normalizeText(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
+
+func tokenize(s string) map[string]bool {
+	tokens := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		tokens[w] = true
+	}
+	return tokens
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for t := range a {
+		if b[t] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+var Seen *SeenStore
+
+type avoidTitlesKey struct{}
+
+// withAvoidTitles adds titles for buildPrompt to steer the model away from.
+func withAvoidTitles(ctx context.Context, titles ...string) context.Context {
+	all := append(avoidTitlesFrom(ctx), titles...)
+	return context.WithValue(ctx, avoidTitlesKey{}, all)
+}
+
+func avoidTitlesFrom(ctx context.Context) []string {
+	titles, _ := ctx.Value(avoidTitlesKey{}).([]string)
+	return titles[:len(titles):len(titles)]
+}
+
+// generateUnique regenerates while the result duplicates a recent prompt,
+// giving up after DedupRegenAttempts regenerations in a row. Each
+// regeneration asks the model to avoid the titles it duplicated. Prompts
+// that break the title, placeholder or moderation rules, or score too low
+// in the quality review, are regenerated as configured too.
+func generateUnique(ctx context.Context) (PromptResponse, error) {
+	sector, err := pickSector()
+	if err != nil {
+		return PromptResponse{}, InStage("sector", err)
+	}
+	runctx.Logln(ctx, "🎯 Sector:", sector)
+	traceFrom(ctx).SetAttr("sector", sector)
+
+	regens, extractionRetries, titleRegens, placeholderRegens := 0, 0, 0, 0
+	moderationRegens, qualityRegens := 0, 0
+	for {
+		p, err := generatePrompt(ctx, sector)
+		var extractErr *extractionError
+		if errors.As(err, &extractErr) && ExtractionFallback == "retry" && extractionRetries < ExtractionRetries {
+			extractionRetries++
+			runctx.Logf(ctx, "🔁 Extraction failed, regenerating (%d/%d)", extractionRetries, ExtractionRetries)
+			continue
+		}
+		if err != nil {
+			return p, err
+		}
+
+		if err := checkTitleRules(p.Title); err != nil {
+			if TitleRuleAction != "regenerate" || titleRegens >= TitleRegenAttempts {
+				runctx.Logln(ctx, "🚫", err)
+				return p, InStage("validation", err)
+			}
+			titleRegens++
+			runctx.Logf(ctx, "🚫 %v, regenerating (%d/%d)", err, titleRegens, TitleRegenAttempts)
+			continue
+		}
+
+		if err := checkPlaceholders(p); err != nil {
+			if PlaceholderAction != "regenerate" || placeholderRegens >= PlaceholderRegenAttempts {
+				runctx.Logln(ctx, "🧩", err)
+				return p, InStage("validation", err)
+			}
+			placeholderRegens++
+			runctx.Logf(ctx, "🧩 %v, regenerating (%d/%d)", err, placeholderRegens, PlaceholderRegenAttempts)
+			continue
+		}
+
+		if err := checkContent(p); err != nil {
+			if ModerationAction != "regenerate" || moderationRegens >= ModerationRegenAttempts {
+				runctx.Logln(ctx, "🛡️", err)
+				return p, InStage("moderation", err)
+			}
+			moderationRegens++
+			runctx.Logf(ctx, "🛡️ %v, regenerating (%d/%d)", err, moderationRegens, ModerationRegenAttempts)
+			ctx = withAvoidTitles(ctx, p.Title)
+			continue
+		}
+
+		match, score, dup := Seen.FindSimilar(p)
+		if !dup {
+			err := checkQuality(ctx, p)
+			var low *lowScoreError
+			if err == nil {
+				return p, nil
+			}
+			if !errors.As(err, &low) || qualityRegens >= QualityRegenAttempts {
+				runctx.Logln(ctx, "❌ Generated prompt failed the quality review:", err)
+				return p, InStage("quality", err)
+			}
+			qualityRegens++
+			runctx.Logf(ctx, "🧑‍⚖️ %v, regenerating (%d/%d)", err, qualityRegens, QualityRegenAttempts)
+			ctx = withAvoidTitles(ctx, p.Title)
+			continue
+		}
+		if regens >= DedupRegenAttempts {
+			runctx.Logf(ctx, "⏭️ Still a duplicate of %q after %d regeneration(s), skipping", match.Title, regens)
+			return p, InStage("dedup", ErrDuplicate)
+		}
+		regens++
+		runctx.Logf(ctx, "♻️ %q is too similar to %q (%.2f), regenerating (%d/%d)", p.Title, match.Title, score, regens, DedupRegenAttempts)
+		ctx = withAvoidTitles(ctx, match.Title, p.Title)
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSeenStoreFindSimilar(t *testing.T) {
+	defer func(n int) { DedupHashHistory = n }(DedupHashHistory)
+	DedupHashHistory = 3
+	s := LoadSeenStore(filepath.Join(t.TempDir(), "seen.json"), 1)
+	for _, p := range []PromptResponse{
+		{Title: "First", Prompt: "Write a launch email for {product}"},
+		{Title: "Second", Prompt: "Summarise {report} in three bullets"},
+		{Title: "Third", Prompt: "Draft a cold outreach message to {lead}"},
+	} {
+		s.Add(p)
+	}
+
+	tests := []struct {
+		name      string
+		p         PromptResponse
+		wantDup   bool
+		wantMatch string
+	}{
+		{
+			name:      "exact repost outside the similarity window",
+			p:         PromptResponse{Title: "Again", Prompt: "write a launch   email for {product}"},
+			wantDup:   true,
+			wantMatch: "First",
+		},
+		{
+			name:      "similar to the window",
+			p:         PromptResponse{Title: "Third", Prompt: "Something else"},
+			wantDup:   true,
+			wantMatch: "Third",
+		},
+		{
+			name: "similar only to an entry outside the window",
+			p:    PromptResponse{Title: "Second", Prompt: "Something else"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, _, dup := s.FindSimilar(tt.p)
+			if dup != tt.wantDup || (dup && match.Title != tt.wantMatch) {
+				t.Errorf("FindSimilar() = %q, %v, want %q, %v", match.Title, dup, tt.wantMatch, tt.wantDup)
+			}
+		})
+	}
+
+	// Once the oldest prompt leaves the history its hash goes with it.
+	s.Add(PromptResponse{Title: "Fourth", Prompt: "Plan a webinar on {topic}"})
+	if match, _, dup := s.FindSimilar(PromptResponse{Title: "Again", Prompt: "Write a launch email for {product}"}); dup {
+		t.Errorf("FindSimilar() matched %q after it left the history", match.Title)
+	}
+}
@@ -0,0 +1,103 @@
+package generator
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExtractJSONBlockWrapperTags(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "json wrapper",
+			in:   `<json>{"title": "A"}</json>`,
+			want: `{"title": "A"}`,
+		},
+		{
+			name: "response wrapper with prose",
+			in:   "Here you go:\n<response>\n{\"title\": \"A\"}\n</response>\nEnjoy!",
+			want: `{"title": "A"}`,
+		},
+		{
+			name: "tag inside a string value",
+			in:   `{"title": "A", "prompt": "Wrap your answer in <output>...</output> tags"}`,
+			want: `{"title": "A", "prompt": "Wrap your answer in <output>...</output> tags"}`,
+		},
+		{
+			name: "wrapper around an object mentioning another tag",
+			in:   `<answer>{"prompt": "Reply inside <json></json>"}</answer>`,
+			want: `{"prompt": "Reply inside <json></json>"}`,
+		},
+		{
+			name: "wrapper needed to skip prose braces",
+			in:   `Fill in {sector} <json>{"title": "A"}</json>`,
+			want: `{"title": "A"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSONBlock(context.Background(), tt.in); got != tt.want {
+				t.Errorf("extractJSONBlock(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONBlockBalancedObject(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "nested example object",
+			in:   `{"title": "A", "example": {"input": {"topic": "x"}, "output": "y"}}`,
+			want: `{"title": "A", "example": {"input": {"topic": "x"}, "output": "y"}}`,
+		},
+		{
+			name: "braces inside strings",
+			in:   `{"prompt": "Write about {topic} and close with }", "title": "A"}`,
+			want: `{"prompt": "Write about {topic} and close with }", "title": "A"}`,
+		},
+		{
+			name: "escaped quote before a brace in a string",
+			in:   `{"prompt": "say \"}\" twice", "title": "A"}`,
+			want: `{"prompt": "say \"}\" twice", "title": "A"}`,
+		},
+		{
+			name: "prose braces before the object",
+			in:   "Use {sector} as the theme.\n{\"title\": \"A\"}",
+			want: `{"title": "A"}`,
+		},
+		{
+			name: "trailing text",
+			in:   `{"title": "A"} Let me know if you want changes {or more}.`,
+			want: `{"title": "A"}`,
+		},
+		{
+			name: "trailing second block",
+			in:   "{\"title\": \"A\"}\n\nExample:\n{\"title\": \"B\"}",
+			want: `{"title": "A"}`,
+		},
+		{
+			name: "raw newline inside a string after prose braces",
+			in:   "Fill {sector} in.\n{\"title\": \"A\", \"prompt\": \"line one\nline two\"}",
+			want: "{\"title\": \"A\", \"prompt\": \"line one\nline two\"}",
+		},
+		{
+			name: "trailing comma and code fence",
+			in:   "```json\n{\"title\": \"A\", \"tags\": [\"x\",],}\n```",
+			want: `{"title": "A", "tags": ["x"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractJSONBlock(context.Background(), tt.in); got != tt.want {
+				t.Errorf("extractJSONBlock(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// extractionError is returned when no JSON object could be parsed out of
+// the model output. It carries the raw text for EXTRACTION_FALLBACK.
+type extractionError struct {
+	raw string
+	err error
+}
+
+func (e *extractionError) Error() string { return "could not extract prompt JSON: " + e.err.Error() }
+
+func (e *extractionError) Unwrap() error { return e.err }
+
+var (
+	ExtractionFallback = "none"
+	ExtractionRetries  = 1
+	DeadLetterFile     = "deadletter.jsonl"
+)
+
+func handleExtractionFailure(ctx context.Context, sector string, e *extractionError) {
+	switch ExtractionFallback {
+	case "deadletter":
+		entry := map[string]interface{}{
+			"timestamp": time.Now(),
+			"sector":    sector,
+			"error":     e.err.Error(),
+			"raw":       e.raw,
+		}
+		if err := appendJSONL(DeadLetterFile, entry); err != nil {
+			runctx.Logln(ctx, "❌ Failed to write dead letter:", err)
+			return
+		}
+		runctx.Logln(ctx, "📪 Stored unparsed response in", DeadLetterFile)
+	case "raw":
+		if DryRun {
+			return
+		}
+		payload := map[string]interface{}{
+			"raw":      e.raw,
+			"unparsed": true,
+			"sector":   sector,
+		}
+		if err := SendPayload(ctx, payload); err != nil {
+			runctx.Logln(ctx, "❌ Failed to send unparsed response:", err)
+			return
+		}
+		runctx.Logln(ctx, "📤 Sent unparsed response to backend")
+	}
+}
@@ -0,0 +1,784 @@
+// Package generator asks a model for prompts, checks them and sends them
+// to the outputs of the job they belong to.
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/approval"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+)
+
+// PromptResponse is a generated prompt as sent to the outputs.
+type PromptResponse struct {
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Prompt      string      `json:"prompt"`
+	UseCases    []string    `json:"useCases"`
+	Example     interface{} `json:"example"`
+	Tags        []string    `json:"tags"`
+	Sector      string      `json:"sector,omitempty"`
+	Slug        string      `json:"slug,omitempty"`
+	Language    string      `json:"language,omitempty"`
+	ImageURL    string      `json:"imageUrl,omitempty"`
+
+	TemplateVersion string `json:"templateVersion,omitempty"`
+
+	// Raw is the model output the prompt was extracted from.
+	Raw string `json:"-"`
+}
+
+type rawPromptResponse struct {
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Prompt      string          `json:"prompt"`
+	UseCases    []string        `json:"useCases"`
+	Example     json.RawMessage `json:"example"`
+	Tags        []string        `json:"tags"`
+}
+
+var (
+	DryRun bool
+	Pretty bool
+
+	GenerateSlug  bool
+	IncludeCounts bool
+)
+
+// GenerateAndSend generates one prompt for the context's job, checks it
+// and sends it to the job's outputs, or prints it with DryRun.
+func GenerateAndSend(ctx context.Context) (structured PromptResponse, err error) {
+	Reconfigure.RLock()
+	defer Reconfigure.RUnlock()
+
+	runID := runctx.RunID(ctx)
+	if runID == "" {
+		runID = runctx.NewRunID()
+		ctx = runctx.WithRunID(ctx, runID)
+	}
+	runctx.Logln(ctx, "🆔 Run ID:", runID)
+
+	if RunDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, RunDeadline)
+		defer cancel()
+	}
+
+	tr := startTrace(ctx, "generate")
+	tr.SetAttr("run.id", runID)
+	ctx = withTrace(ctx, tr)
+	defer func() { tr.Finish(err) }()
+
+	if err = llm.Usage.CheckBudget(); err != nil {
+		runctx.Logln(ctx, "⛔ Skipping run:", err)
+		return structured, InStage("budget", err)
+	}
+
+	if SchemaFrom(ctx) != nil {
+		if structured.Sector, err = pickSector(); err != nil {
+			return structured, InStage("sector", err)
+		}
+		runctx.Logln(ctx, "🎯 Sector:", structured.Sector)
+		tr.SetAttr("sector", structured.Sector)
+		err = generateAndSendDocument(ctx, structured.Sector)
+		return structured, err
+	}
+
+	structured, err = generateUnique(ctx)
+	var extractErr *extractionError
+	if errors.As(err, &extractErr) {
+		handleExtractionFailure(ctx, structured.Sector, extractErr)
+	}
+	if err != nil {
+		return structured, err
+	}
+
+	if GenerateSlug {
+		structured.Slug = Seen.UniqueSlug(structured)
+	}
+
+	if DryRun {
+		return structured, PrintPrompt(structured, Pretty)
+	}
+	if err = attachImage(ctx, &structured); err != nil {
+		return structured, err
+	}
+	backupPrompt(ctx, structured)
+	if sendDeferred(ctx) {
+		return structured, nil
+	}
+
+	// Concurrent runs only see each other's prompts once they are sent, so
+	// the duplicate check is repeated under SendMu.
+	SendMu.Lock()
+	defer SendMu.Unlock()
+	if match, _, dup := Seen.FindSimilar(structured); dup {
+		runctx.Logf(ctx, "⏭️ %q duplicates %q sent by a concurrent run, skipping", structured.Title, match.Title)
+		return structured, InStage("dedup", ErrDuplicate)
+	}
+
+	sendSpan := tr.StartSpan("backend.send")
+	err = sendToBackend(ctx, structured)
+	sendSpan.End(err)
+	if err != nil && !Delivered(err) {
+		runctx.Logln(ctx, "❌ Failed to send prompt:", err)
+		return structured, InStage("backend", err)
+	}
+	if approval.Enabled {
+		// The prompt is marked sent once a reviewer approves it.
+		return structured, nil
+	}
+
+	MarkSent(ctx, structured)
+	if err != nil {
+		runctx.Logln(ctx, "⚠️ Prompt saved, but some outputs failed and will be replayed:", err)
+		return structured, err
+	}
+	runctx.Logln(ctx, "✅ Prompt saved successfully!")
+	return structured, nil
+}
+
+var SendMu sync.Mutex
+
+// Reconfigure is held for reading by each run and send, and for writing
+// while rotated secrets are applied, so a run never sees credentials half
+// updated.
+var Reconfigure sync.RWMutex
+
+// MarkSent updates the dedup store and sector counts after a prompt has
+// reached the backend.
+func MarkSent(ctx context.Context, p PromptResponse) {
+	markSeen(ctx, p)
+	SectorCounts.Inc(p.Sector)
+}
+
+// markSeen is MarkSent for a translation, which only adds it to the dedup
+// store; its source prompt already counted towards the sector.
+func markSeen(ctx context.Context, p PromptResponse) {
+	if err := Seen.Add(p); err != nil {
+		runctx.Logln(ctx, "⚠️ Failed to update dedup store:", err)
+	}
+}
+
+// heldEntry is what an approval item keeps to record its prompt or
+// document as sent once it is approved.
+type heldEntry struct {
+	PromptResponse
+	Translation bool `json:"translation,omitempty"`
+}
+
+// MarkApproved is MarkSent for the entry an approval item was held with,
+// called once the item has been approved and delivered.
+func MarkApproved(ctx context.Context, entry []byte) {
+	if len(entry) == 0 {
+		return
+	}
+	var held heldEntry
+	if err := json.Unmarshal(entry, &held); err != nil {
+		runctx.Logln(ctx, "⚠️ Could not decode the approved entry:", err)
+		return
+	}
+	if held.Translation {
+		markSeen(ctx, held.PromptResponse)
+		return
+	}
+	MarkSent(ctx, held.PromptResponse)
+}
+
+// generatePrompt builds the prompt for the sector and asks the model for a
+// PromptResponse, sending malformed or invalid output back to be fixed up
+// to JSONReaskAttempts times.
+func generatePrompt(ctx context.Context, sector string) (PromptResponse, error) {
+	prompt, version, err := buildPrompt(ctx, sector)
+	if err != nil {
+		runctx.Logln(ctx, "❌ Failed to build generation prompt:", err)
+		return PromptResponse{Sector: sector}, InStage("prompt", err)
+	}
+	return askForPrompt(ctx, prompt, sector, version)
+}
+
+// askForPrompt sends prompt to the model and parses the PromptResponse,
+// with the re-asks of generatePrompt.
+func askForPrompt(ctx context.Context, prompt, sector, version string) (PromptResponse, error) {
+	var structured PromptResponse
+	err := withReasks(ctx, prompt, func(ask string) (string, error) {
+		var output string
+		var err error
+		structured, output, err = parsePrompt(ctx, ask, sector, version)
+		return output, err
+	})
+	return structured, err
+}
+
+// withReasks calls attempt with prompt and, while the output it returns
+// cannot be extracted or fails validation, with a request to fix it, up to
+// JSONReaskAttempts times.
+func withReasks(ctx context.Context, prompt string, attempt func(ask string) (string, error)) error {
+	ask := prompt
+	for reasks := 0; ; reasks++ {
+		output, err := attempt(ask)
+		var extractErr *extractionError
+		switch {
+		case err == nil:
+			return nil
+		case errors.As(err, &extractErr):
+			output = extractErr.raw
+		case FailureStage(err) != "validation":
+			return err
+		}
+		if reasks >= JSONReaskAttempts || output == "" {
+			return err
+		}
+		runctx.Logf(ctx, "🩹 Asking the model to fix its response (%d/%d)", reasks+1, JSONReaskAttempts)
+		ask = fixPrompt(prompt, output, err)
+	}
+}
+
+// JSONReaskAttempts is how many times a malformed or invalid response is
+// sent back to the model to be fixed before the run gives up.
+var JSONReaskAttempts = 2
+
+// fixPrompt asks the model to correct a response that could not be used,
+// repeating the original request so it still knows the expected keys.
+func fixPrompt(prompt, output string, problem error) string {
+	return "Your previous response to the request below could not be used: " + problem.Error() +
+		"\n\nRequest:\n" + prompt +
+		"\n\nYour previous response:\n" + output +
+		"\n\nReply with the corrected JSON object only, without any extra commentary or Markdown."
+}
+
+// parsePrompt makes one model call and turns the output into a validated
+// PromptResponse. It also returns the model output for a re-ask.
+func parsePrompt(ctx context.Context, prompt, sector, version string) (PromptResponse, string, error) {
+	tr := traceFrom(ctx)
+
+	var raw rawPromptResponse
+	rawResponse, err := fetchJSON(ctx, prompt, &raw)
+	if err != nil {
+		return PromptResponse{Sector: sector}, "", err
+	}
+
+	example := decodeExample(raw.Example)
+
+	structured := PromptResponse{
+		Title:       raw.Title,
+		Description: raw.Description,
+		Prompt:      raw.Prompt,
+		UseCases:    raw.UseCases,
+		Tags:        raw.Tags,
+		Example:     example,
+		Sector:      sector,
+		Language:    LocaleFrom(ctx),
+
+		TemplateVersion: version,
+		Raw:             rawResponse,
+	}
+
+	validateSpan := tr.StartSpan("validate")
+	applyTagFallback(ctx, &structured)
+	dropEmptyUseCases(ctx, &structured)
+	if SanitizeExample {
+		structured.Example = sanitizeExample(structured.Example)
+	}
+
+	err = structured.Validate()
+	validateSpan.End(err)
+	if err != nil {
+		runctx.Logln(ctx, "❌ Generated prompt failed validation:", err)
+		return structured, rawResponse, InStage("validation", err)
+	}
+
+	return structured, rawResponse, nil
+}
+
+// fetchJSON asks the model for a JSON object and decodes it into v, trying
+// JSON mode or tool calling first when STRUCTURED_OUTPUT asks for it and
+// falling back to text extraction. It returns the model output the JSON
+// was taken from.
+func fetchJSON(ctx context.Context, prompt string, v interface{}) (string, error) {
+	tr := traceFrom(ctx)
+
+	llmSpan := tr.StartSpan("llm.call")
+	var (
+		cleanedJSON, rawResponse string
+		err                      error
+	)
+	switch provider := llmFrom(ctx); {
+	case StructuredOutput == "tools" && SchemaFrom(ctx) == nil:
+		args, err := getPromptViaToolCall(ctx, prompt)
+		if err != nil {
+			runctx.Logln(ctx, "⚠️ Tool calling failed, falling back to text extraction:", err)
+		} else {
+			runctx.Logln(ctx, "🛠️ Tool call arguments:\n", args)
+			cleanedJSON = args
+		}
+	case StructuredOutput == "json":
+		jsonGen, ok := provider.(llm.JSONGenerator)
+		if !ok {
+			runctx.Logf(ctx, "⚠️ %s has no JSON mode, using text extraction", provider.Name())
+			break
+		}
+		text, err := jsonGen.GenerateJSON(ctx, prompt)
+		if err != nil {
+			runctx.Logln(ctx, "⚠️ JSON mode failed, falling back to text extraction:", err)
+			break
+		}
+		runctx.Logf(ctx, "📥 Raw %s JSON-mode response:\n %s", provider.Name(), text)
+		rawResponse, cleanedJSON = text, strings.TrimSpace(text)
+	}
+
+	if cleanedJSON == "" {
+		rawResponse, err = llmFrom(ctx).Generate(ctx, prompt)
+		if err != nil {
+			llmSpan.End(err)
+			runctx.Logf(ctx, "❌ Failed to get prompt from %s: %v", llmFrom(ctx).Name(), err)
+			return "", InStage("llm", err)
+		}
+
+		runctx.Logf(ctx, "📥 Raw %s Response:\n %s", llmFrom(ctx).Name(), rawResponse)
+	}
+	llmSpan.End(nil)
+
+	extractSpan := tr.StartSpan("extract")
+	// On a timeout the closure keeps running, so it only reads copies and
+	// decodes into a fresh value, handed back over results once it is done.
+	type extracted struct {
+		cleaned string
+		value   reflect.Value
+	}
+	results := make(chan extracted, 1)
+	raw, input := rawResponse, cleanedJSON
+	target := reflect.TypeOf(v).Elem()
+	err = runctx.WithinTimeout(ExtractTimeout, "extraction", func() error {
+		cleaned := input
+		if cleaned == "" {
+			cleaned = extractJSONBlock(ctx, raw)
+			runctx.Logln(ctx, "🧼 Cleaned JSON:\n", cleaned)
+		}
+
+		value := reflect.New(target)
+		err := json.Unmarshal([]byte(cleaned), value.Interface())
+		if err != nil {
+			if repaired, n := escapeControlCharsInStrings(cleaned); n > 0 {
+				runctx.Logf(ctx, "🔧 Escaped %d control character(s) inside JSON strings, retrying parse", n)
+				value = reflect.New(target)
+				if err = json.Unmarshal([]byte(repaired), value.Interface()); err == nil {
+					cleaned = repaired
+				}
+			}
+		}
+		results <- extracted{cleaned: cleaned, value: value}
+		return err
+	})
+	select {
+	case res := <-results:
+		cleanedJSON = res.cleaned
+		if err == nil {
+			reflect.ValueOf(v).Elem().Set(res.value.Elem())
+		}
+	default:
+		// Timed out before the closure finished.
+	}
+	if err != nil {
+		extractSpan.End(err)
+		runctx.Logf(ctx, "❌ Failed to parse model response.\nCleaned JSON:\n%s\nError: %v", cleanedJSON, err)
+		if rawResponse == "" {
+			rawResponse = cleanedJSON
+		}
+		return "", &extractionError{raw: rawResponse, err: err}
+	}
+
+	extractSpan.End(nil)
+	if rawResponse == "" {
+		rawResponse = cleanedJSON
+	}
+	return rawResponse, nil
+}
+
+func sendToBackend(ctx context.Context, prompt PromptResponse) error {
+	return sendPayload(ctx, BuildPayload(ctx, prompt), &heldEntry{PromptResponse: prompt})
+}
+
+// BuildPayload turns a prompt into the JSON object the outputs receive.
+func BuildPayload(ctx context.Context, prompt PromptResponse) map[string]interface{} {
+	payload := map[string]interface{}{
+		"title":       prompt.Title,
+		"description": prompt.Description,
+		"tags":        prompt.Tags,
+		"prompt":      prompt.Prompt,
+		"useCases":    prompt.UseCases,
+		"example":     prompt.Example,
+
+		"templateVersion": prompt.TemplateVersion,
+	}
+	if prompt.Slug != "" {
+		payload["slug"] = prompt.Slug
+	}
+	if prompt.Language != "" {
+		payload["language"] = prompt.Language
+	}
+	if prompt.ImageURL != "" {
+		payload[ImageField] = prompt.ImageURL
+	}
+	if IncludeCounts {
+		payload["promptChars"] = utf8.RuneCountInString(prompt.Prompt)
+		payload["promptWords"] = len(strings.Fields(prompt.Prompt))
+	}
+	storeRaw(ctx, payload, prompt.Sector, prompt.Raw)
+	return payload
+}
+
+// StampPayload adds the send time and PAYLOAD_METADATA to a payload.
+func StampPayload(payload map[string]interface{}) {
+	payload[TimestampField] = time.Now()
+	if len(PayloadMetadata) > 0 {
+		payload["metadata"] = PayloadMetadata
+	}
+}
+
+// SendPayload stamps and fans a payload out to every configured sink, or
+// holds it for review in APPROVAL_MODE. A *UnsentError with Sent set means
+// the primary sink took the payload and only others failed.
+func SendPayload(ctx context.Context, payload map[string]interface{}) error {
+	return sendPayload(ctx, payload, nil)
+}
+
+// sendPayload is SendPayload for a generated prompt or document. In
+// APPROVAL_MODE the item keeps entry, so the dedup store and sector counts
+// only learn about it once it is approved.
+func sendPayload(ctx context.Context, payload map[string]interface{}, entry *heldEntry) error {
+	StampPayload(payload)
+	jsonPayload, _ := json.Marshal(payload)
+	if approval.Enabled {
+		var held []byte
+		if entry != nil {
+			held, _ = json.Marshal(entry)
+		}
+		approval.Queue.Submit(ctx, jsonPayload, held)
+		return nil
+	}
+	return Deliver(ctx, jsonPayload, nil).Err()
+}
+
+// SinkResult is one sink's outcome of a delivery.
+type SinkResult struct {
+	Sink string
+	Err  error
+}
+
+// Delivery is the outcome of handing one payload to the sinks.
+type Delivery struct {
+	Payload []byte
+	Results []SinkResult
+}
+
+// Deliver fans an already stamped payload out to the configured sinks, or
+// only to those named in only, e.g. the ones a replay still has to reach.
+func Deliver(ctx context.Context, jsonPayload []byte, only []string) *Delivery {
+	RecordPayload(ctx, jsonPayload)
+
+	d := &Delivery{Payload: jsonPayload}
+	for _, sink := range SinksFrom(ctx) {
+		if only != nil && !containsString(only, sink.Name()) {
+			continue
+		}
+		sinkCtx, cancel := runctx.WithStageTimeout(ctx, output.BackendTimeout)
+		err := sink.Send(sinkCtx, jsonPayload)
+		if err != nil {
+			err = runctx.StageError(ctx, sinkCtx, "backend", output.BackendTimeout, err)
+		}
+		cancel()
+		d.Results = append(d.Results, SinkResult{Sink: sink.Name(), Err: err})
+	}
+	return d
+}
+
+// Failed names the sinks that rejected the payload.
+func (d *Delivery) Failed() []string {
+	var failed []string
+	for _, r := range d.Results {
+		if r.Err != nil {
+			failed = append(failed, r.Sink)
+		}
+	}
+	return failed
+}
+
+// Sent reports whether the primary sink took the payload: the backend when
+// it is an output, otherwise the first one.
+func (d *Delivery) Sent() bool {
+	if len(d.Results) == 0 {
+		return true
+	}
+	primary := d.Results[0]
+	for _, r := range d.Results {
+		if r.Sink == "backend" {
+			primary = r
+			break
+		}
+	}
+	return primary.Err == nil
+}
+
+// Err returns nil when every sink took the payload, otherwise a
+// *UnsentError naming the sinks it still has to reach.
+func (d *Delivery) Err() error {
+	var errs []error
+	for _, r := range d.Results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", r.Sink, r.Err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &UnsentError{Payload: d.Payload, Sinks: d.Failed(), Sent: d.Sent(), Err: errors.Join(errs...)}
+}
+
+// SentPayload is the most recent payload handed to the outputs.
+type SentPayload struct {
+	RunID     string          `json:"runId"`
+	Job       string          `json:"job,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+var (
+	lastPayloadMu sync.Mutex
+	lastPayload   *SentPayload
+)
+
+// RecordPayload remembers payload as the run's latest, for LastPayload.
+func RecordPayload(ctx context.Context, payload []byte) {
+	lastPayloadMu.Lock()
+	lastPayload = &SentPayload{RunID: runctx.RunID(ctx), Job: runctx.JobName(ctx), Timestamp: time.Now(), Payload: payload}
+	lastPayloadMu.Unlock()
+}
+
+// LastPayload returns the most recent payload handed to the outputs, or
+// nil before the first send.
+func LastPayload() *SentPayload {
+	lastPayloadMu.Lock()
+	defer lastPayloadMu.Unlock()
+	return lastPayload
+}
+
+type wrapperTag struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var wrapperTags = func() []wrapperTag {
+	var tags []wrapperTag
+	for _, name := range []string{"json", "answer", "response", "output", "result"} {
+		tags = append(tags, wrapperTag{name, regexp.MustCompile(`(?is)<` + name + `>(.*?)</` + name + `>`)})
+	}
+	return tags
+}()
+
+// stripWrapperTags unwraps XML-like tags some models put around their JSON,
+// e.g. <json>{...}</json>, returning the inner text and the tag removed.
+func stripWrapperTags(text string) (string, string) {
+	for _, tag := range wrapperTags {
+		if m := tag.re.FindStringSubmatch(text); m != nil {
+			return m[1], tag.name
+		}
+	}
+	return text, ""
+}
+
+// extractJSONBlock finds the prompt object in the model's text. Wrapper
+// tags are only stripped when no object parses as it is, since a prompt
+// may itself mention <output>...</output> inside a string value.
+func extractJSONBlock(ctx context.Context, text string) string {
+	match := balancedObject(text)
+	if !validObject(match) {
+		if inner, tag := stripWrapperTags(text); tag != "" {
+			if unwrapped := balancedObject(inner); validObject(unwrapped) || match == "" {
+				runctx.Logf(ctx, "🏷️ Removed <%s> wrapper from model output", tag)
+				match = unwrapped
+			}
+		}
+	}
+
+	match = trailingCommaRe.ReplaceAllString(match, "$1")
+	match = strings.TrimSpace(match)
+	match = strings.TrimPrefix(match, "```json")
+	match = strings.TrimSuffix(match, "```")
+
+	return match
+}
+
+var trailingCommaRe = regexp.MustCompile(`,\s*([\]}])`)
+
+// balancedObject returns the first brace-balanced {...} in text that
+// parses as JSON once repaired, so braces in surrounding prose or a second
+// block after the object are ignored. If none parses it returns the first
+// balanced candidate (or everything from the first "{" when it never
+// closes) for the error log downstream.
+func balancedObject(text string) string {
+	first := ""
+	for start := strings.IndexByte(text, '{'); start >= 0; {
+		end := matchingBrace(text, start)
+		if end < 0 {
+			if first == "" {
+				first = text[start:]
+			}
+			break
+		}
+		candidate := text[start : end+1]
+		if validObject(candidate) {
+			return candidate
+		}
+		if first == "" {
+			first = candidate
+		}
+		// Resume after the candidate so its own nested objects are not tried.
+		next := strings.IndexByte(text[end+1:], '{')
+		if next < 0 {
+			break
+		}
+		start = end + 1 + next
+	}
+	return first
+}
+
+// validObject reports whether s parses as JSON once trailing commas are
+// dropped and raw control characters in strings escaped, the repairs
+// applied downstream.
+func validObject(s string) bool {
+	if s == "" {
+		return false
+	}
+	repaired, _ := escapeControlCharsInStrings(trailingCommaRe.ReplaceAllString(s, "$1"))
+	return json.Valid([]byte(repaired))
+}
+
+// matchingBrace returns the index of the "}" closing the "{" at start,
+// skipping braces inside string literals, or -1 if it is never closed.
+func matchingBrace(text string, start int) int {
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// escapeControlCharsInStrings escapes raw control characters (typically
+// literal newlines) that appear inside JSON string literals. It returns the
+// repaired text and how many characters were escaped.
+func escapeControlCharsInStrings(text string) (string, int) {
+	var b strings.Builder
+	inString, escaped, count := false, false, 0
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString && c < 0x20:
+			count++
+			switch c {
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				fmt.Fprintf(&b, `\u%04x`, c)
+			}
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), count
+}
+
+type deferSendKey struct{}
+
+// WithDeferredSend makes GenerateAndSend stop before the backend send so
+// the caller can post the prompt as part of a bulk request.
+func WithDeferredSend(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferSendKey{}, true)
+}
+
+func sendDeferred(ctx context.Context) bool {
+	deferred, _ := ctx.Value(deferSendKey{}).(bool)
+	return deferred
+}
+
+var (
+	TimestampField = "createdAt"
+
+	PayloadMetadata map[string]interface{}
+	ExtractTimeout  = 5 * time.Second
+	RunDeadline     = time.Minute
+)
+
+// Validate checks a generated prompt before it is sent, reporting every
+// failing field at once.
+func (p PromptResponse) Validate() error {
+	var problems []error
+
+	for _, f := range []struct{ name, value string }{
+		{"title", p.Title},
+		{"description", p.Description},
+		{"prompt", p.Prompt},
+	} {
+		if strings.TrimSpace(f.value) == "" {
+			problems = append(problems, fmt.Errorf("%s: must not be empty", f.name))
+		}
+	}
+
+	if n := len(p.Tags); n < TagMin || n > TagMax {
+		problems = append(problems, fmt.Errorf("tags: got %d, want %d–%d", n, TagMin, TagMax))
+	}
+	for _, t := range p.Tags {
+		if t != strings.ToLower(t) {
+			problems = append(problems, fmt.Errorf("tags: %q is not lowercase", t))
+		}
+	}
+	if n := len(p.UseCases); n < UseCaseMin || n > UseCaseMax {
+		problems = append(problems, fmt.Errorf("useCases: got %d, want %d–%d", n, UseCaseMin, UseCaseMax))
+	}
+
+	if ExampleCount > 1 {
+		if items, ok := p.Example.([]interface{}); !ok || len(items) != ExampleCount {
+			problems = append(problems, fmt.Errorf("example: want an array of %d examples", ExampleCount))
+		}
+	}
+
+	return JoinProblems(problems)
+}
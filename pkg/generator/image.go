@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+)
+
+var (
+	// ImageField is the payload key for the header image URL.
+	ImageField = "imageUrl"
+	// ImageStyle is appended to the image description, e.g. "flat vector
+	// illustration, pastel colours".
+	ImageStyle string
+	// ImageRequired fails the run when no image could be attached instead
+	// of sending the prompt without one.
+	ImageRequired bool
+
+	// Images generates the header images; nil disables them.
+	Images *llm.ImageGenerator
+)
+
+// attachImage generates a header image for the prompt and sets its URL,
+// when an image generator is configured.
+func attachImage(ctx context.Context, p *PromptResponse) error {
+	link, err := headerImage(ctx, *p, imageDescription(*p))
+	if link != "" {
+		p.ImageURL = link
+	}
+	return err
+}
+
+// attachDocumentImage is attachImage for a schema document, which gets
+// the URL under ImageField.
+func attachDocumentImage(ctx context.Context, doc map[string]interface{}, entry PromptResponse) error {
+	link, err := headerImage(ctx, entry, documentImageDescription(ctx, doc, entry))
+	if link != "" {
+		doc[ImageField] = link
+	}
+	return err
+}
+
+// headerImage generates and uploads an image from description. It returns
+// "" without an image generator, and without an error when the image is
+// optional and failed.
+func headerImage(ctx context.Context, p PromptResponse, description string) (string, error) {
+	if Images == nil {
+		return "", nil
+	}
+	span := traceFrom(ctx).StartSpan("image")
+	link, err := generateImage(ctx, p, description)
+	span.End(err)
+	if err != nil {
+		if ImageRequired {
+			runctx.Logln(ctx, "❌ Failed to attach an image:", err)
+			return "", InStage("image", err)
+		}
+		runctx.Logln(ctx, "⚠️ Sending without an image:", err)
+		return "", nil
+	}
+	runctx.Logln(ctx, "🖼️ Image uploaded:", link)
+	return link, nil
+}
+
+func generateImage(ctx context.Context, p PromptResponse, description string) (string, error) {
+	img, err := Images.Generate(ctx, description)
+	if err != nil {
+		return "", fmt.Errorf("generating: %w", err)
+	}
+	ext := ".png"
+	switch img.ContentType {
+	case "image/jpeg":
+		ext = ".jpg"
+	case "image/webp":
+		ext = ".webp"
+	}
+	name := "images/" + runctx.RunID(ctx) + ext
+	if p.Slug != "" {
+		name = "images/" + p.Slug + "-" + runctx.RunID(ctx) + ext
+	}
+	link, err := output.UploadImage(ctx, name, img.ContentType, img.Data)
+	if err != nil {
+		return "", fmt.Errorf("uploading: %w", err)
+	}
+	return link, nil
+}
+
+// imageDescription derives the image prompt from the generated content.
+// Image models render text badly, so it asks for none.
+func imageDescription(p PromptResponse) string {
+	return imageDescriptionFrom(fmt.Sprintf("Header image for an article about an AI prompt called %q: %s", p.Title, p.Description), p.Sector)
+}
+
+// documentImageDescription is imageDescription for a schema document,
+// described by its title and its description or summary.
+func documentImageDescription(ctx context.Context, doc map[string]interface{}, entry PromptResponse) string {
+	kind := "document"
+	if ct := contentTypeFrom(ctx); ct != nil {
+		kind = ct.Name
+	}
+	about := fmt.Sprintf("Header image for a %s titled %q", kind, entry.Title)
+	for _, key := range []string{"description", "summary"} {
+		if s, ok := doc[key].(string); ok && strings.TrimSpace(s) != "" {
+			about += ": " + strings.TrimSpace(s)
+			break
+		}
+	}
+	return imageDescriptionFrom(about, entry.Sector)
+}
+
+func imageDescriptionFrom(about, sector string) string {
+	parts := []string{about}
+	if sector != "" {
+		parts = append(parts, "Sector: "+sector+".")
+	}
+	if ImageStyle != "" {
+		parts = append(parts, "Style: "+ImageStyle+".")
+	}
+	parts = append(parts, "No text, letters or logos in the image.")
+	return strings.Join(parts, " ")
+}
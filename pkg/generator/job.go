@@ -0,0 +1,126 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"text/template"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/sources"
+)
+
+// Job is a generation with its own prompt template, model and destination.
+// Unset fields fall back to the global configuration.
+type Job struct {
+	Name     string
+	Template string
+	Model    string
+	Backend  string
+	// Outputs and Publishers replace OUTPUTS and PUBLISHERS for the job
+	// when set.
+	Outputs    []string
+	Publishers []string
+	// Locales replace LOCALES for the job when set.
+	Locales []string
+	// ContentType names the job's content type, replacing CONTENT_TYPE
+	// when set.
+	ContentType string
+	// Sources replace SOURCES for the job when set.
+	Sources []string
+
+	template       *template.Template
+	templateSource string
+	provider       llm.Provider
+	sinks          []output.Sink
+	content        *ContentType
+}
+
+// LoadTemplate parses the job's Template file, if it has one.
+func (j *Job) LoadTemplate() error {
+	if j.Template == "" {
+		return nil
+	}
+	text, err := os.ReadFile(j.Template)
+	if err != nil {
+		return err
+	}
+	if j.template, err = template.New(j.Name).Parse(string(text)); err != nil {
+		return err
+	}
+	j.templateSource = string(text)
+	return nil
+}
+
+// LoadContentType resolves the job's ContentType among ContentTypes.
+func (j *Job) LoadContentType() error {
+	if j.ContentType == "" {
+		return nil
+	}
+	var err error
+	j.content, err = FindContentType(j.ContentType)
+	return err
+}
+
+// Setup builds the job's provider and sinks once the global configuration
+// is loaded.
+func (j *Job) Setup() {
+	if j.Model != "" {
+		j.provider = llm.WithModel(LLM, j.Model)
+	}
+	if j.Backend != "" || j.Outputs != nil || j.Publishers != nil {
+		backend, outputs, publishers := j.Backend, j.Outputs, j.Publishers
+		if backend == "" {
+			backend = output.BackendAPI
+		}
+		if outputs == nil {
+			outputs = output.Outputs
+		}
+		if publishers == nil {
+			publishers = output.Publishers
+		}
+		j.sinks = output.BuildSinks(backend, outputs, publishers)
+	}
+}
+
+type jobKey struct{}
+
+// WithJob makes a run belong to j.
+func WithJob(ctx context.Context, j *Job) context.Context {
+	ctx = runctx.WithJobName(ctx, j.Name)
+	return context.WithValue(ctx, jobKey{}, j)
+}
+
+// JobFrom returns the job a run belongs to, or nil for the default job.
+func JobFrom(ctx context.Context) *Job {
+	j, _ := ctx.Value(jobKey{}).(*Job)
+	return j
+}
+
+// LLM is the provider of the default job and of jobs without a model.
+var LLM llm.Provider
+
+// llmFrom returns the provider for the run's job.
+func llmFrom(ctx context.Context) llm.Provider {
+	if j := JobFrom(ctx); j != nil && j.provider != nil {
+		return j.provider
+	}
+	return LLM
+}
+
+// sourcesFrom returns the feeds and pages the run is grounded in.
+func sourcesFrom(ctx context.Context) []string {
+	if j := JobFrom(ctx); j != nil && j.Sources != nil {
+		return j.Sources
+	}
+	return sources.URLs
+}
+
+// SinksFrom returns the destinations for the run's job.
+func SinksFrom(ctx context.Context) []output.Sink {
+	if j := JobFrom(ctx); j != nil && j.sinks != nil {
+		return j.sinks
+	}
+	return output.Sinks
+}
@@ -0,0 +1,172 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/approval"
+	"promptcraft-groq/pkg/llm"
+)
+
+var (
+	// Locales are the languages every prompt is produced in, unless a job
+	// lists its own. Empty leaves the language to the template.
+	Locales []string
+
+	// LocaleMode is "generate" (a separate generation per locale) or
+	// "translate" (one generation in the first locale, translated into
+	// the others).
+	LocaleMode = "generate"
+)
+
+var languageNames = map[string]string{
+	"ar": "Arabic", "de": "German", "en": "English", "es": "Spanish",
+	"fr": "French", "hi": "Hindi", "it": "Italian", "ja": "Japanese",
+	"ko": "Korean", "nl": "Dutch", "pl": "Polish", "pt": "Portuguese",
+	"ru": "Russian", "sv": "Swedish", "tr": "Turkish", "zh": "Chinese",
+}
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// ParseLocales parses a comma-separated list of locale codes such as
+// "en,es,de" or "pt-BR".
+func ParseLocales(s string) ([]string, error) {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		code := strings.TrimSpace(part)
+		if code == "" {
+			continue
+		}
+		if !localePattern.MatchString(code) {
+			return nil, fmt.Errorf("%q is not a locale code like en or pt-BR", code)
+		}
+		out = appendUnique(out, code)
+	}
+	return out, nil
+}
+
+// languageName names the language of a locale for the model, e.g. "German"
+// for de or "Portuguese (BR)" for pt-BR. Unknown codes are used as is.
+func languageName(locale string) string {
+	lang, region, _ := strings.Cut(locale, "-")
+	name, ok := languageNames[lang]
+	if !ok {
+		return locale
+	}
+	if region != "" {
+		name += " (" + region + ")"
+	}
+	return name
+}
+
+type localeKey struct{}
+
+// WithLocale makes a run write in locale.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// LocaleFrom returns the locale a run writes in, or "".
+func LocaleFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// LocalesFrom returns the locales of the run's job.
+func LocalesFrom(ctx context.Context) []string {
+	if j := JobFrom(ctx); j != nil && j.Locales != nil {
+		return j.Locales
+	}
+	return Locales
+}
+
+// TranslateAndSend translates p into the run's locale, checks it like a
+// generated prompt and sends it.
+func TranslateAndSend(ctx context.Context, p PromptResponse) (structured PromptResponse, err error) {
+	Reconfigure.RLock()
+	defer Reconfigure.RUnlock()
+
+	runctx.Logf(ctx, "🆔 Run ID: %s (translation of %q)", runctx.RunID(ctx), p.Title)
+	if RunDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, RunDeadline)
+		defer cancel()
+	}
+
+	tr := startTrace(ctx, "translate")
+	tr.SetAttr("run.id", runctx.RunID(ctx))
+	tr.SetAttr("locale", LocaleFrom(ctx))
+	ctx = withTrace(ctx, tr)
+	defer func() { tr.Finish(err) }()
+
+	if err = llm.Usage.CheckBudget(); err != nil {
+		runctx.Logln(ctx, "⛔ Skipping translation:", err)
+		return structured, InStage("budget", err)
+	}
+
+	structured, err = translatePrompt(ctx, p)
+	if err != nil {
+		return structured, err
+	}
+	if err := checkContent(structured); err != nil {
+		runctx.Logln(ctx, "🛡️", err)
+		return structured, InStage("moderation", err)
+	}
+	if err := checkQuality(ctx, structured); err != nil {
+		runctx.Logln(ctx, "❌ Translated prompt failed the quality review:", err)
+		return structured, InStage("quality", err)
+	}
+	structured.Slug = p.Slug
+	structured.ImageURL = p.ImageURL
+	if DryRun {
+		return structured, PrintPrompt(structured, Pretty)
+	}
+
+	backupPrompt(ctx, structured)
+	SendMu.Lock()
+	defer SendMu.Unlock()
+	sendSpan := tr.StartSpan("backend.send")
+	err = sendPayload(ctx, BuildPayload(ctx, structured), &heldEntry{PromptResponse: structured, Translation: true})
+	sendSpan.End(err)
+	if err != nil && !Delivered(err) {
+		runctx.Logln(ctx, "❌ Failed to send prompt:", err)
+		return structured, InStage("backend", err)
+	}
+	if approval.Enabled {
+		// The translation is marked sent once a reviewer approves it.
+		return structured, nil
+	}
+
+	markSeen(ctx, structured)
+	if err != nil {
+		runctx.Logln(ctx, "⚠️ Translation saved, but some outputs failed and will be replayed:", err)
+		return structured, err
+	}
+	runctx.Logf(ctx, "✅ %s translation saved successfully!", languageName(LocaleFrom(ctx)))
+	return structured, nil
+}
+
+// translatePrompt asks the model for p in the run's locale.
+func translatePrompt(ctx context.Context, p PromptResponse) (PromptResponse, error) {
+	source, _ := json.MarshalIndent(map[string]interface{}{
+		"title":       p.Title,
+		"description": p.Description,
+		"prompt":      p.Prompt,
+		"useCases":    p.UseCases,
+		"tags":        p.Tags,
+		"example":     p.Example,
+	}, "", "  ")
+	prompt := "Translate the AI prompt below into " + languageName(LocaleFrom(ctx)) + ".\n\n" +
+		"Translate every value, keeping the JSON keys, the structure and any {placeholders} unchanged, and keep the tags lowercase.\n\n" +
+		"Output your response ONLY as a JSON object, without any extra commentary or Markdown.\n\n" + string(source)
+
+	structured, err := askForPrompt(ctx, prompt, p.Sector, p.TemplateVersion)
+	if err == nil {
+		runctx.Logf(ctx, "🌐 Translated %q into %s", p.Title, languageName(LocaleFrom(ctx)))
+	}
+	return structured, err
+}
@@ -0,0 +1,97 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	colorReset  = "\x1b[0m"
+	colorKey    = "\x1b[36m"
+	colorString = "\x1b[32m"
+	colorNumber = "\x1b[33m"
+	colorLit    = "\x1b[35m"
+)
+
+// PrintPrompt writes the prompt to stdout, compact by default so it can be
+// piped, or indented (and colorized on a terminal) when pretty is set.
+func PrintPrompt(p PromptResponse, pretty bool) error {
+	return printJSON(p, pretty)
+}
+
+func printJSON(v interface{}, pretty bool) error {
+	if !pretty {
+		out, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	text := string(out)
+	if isTerminal(os.Stdout) {
+		text = colorizeJSON(text)
+	}
+	fmt.Println(text)
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorizeJSON adds ANSI colors to already-valid JSON text.
+func colorizeJSON(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end++
+			if end > len(s) {
+				end = len(s)
+			}
+			color := colorString
+			if rest := strings.TrimLeft(s[end:], " "); strings.HasPrefix(rest, ":") {
+				color = colorKey
+			}
+			b.WriteString(color + s[i:end] + colorReset)
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(s) && strings.IndexByte("0123456789.eE+-", s[end]) >= 0 {
+				end++
+			}
+			b.WriteString(colorNumber + s[i:end] + colorReset)
+			i = end
+		case strings.HasPrefix(s[i:], "true"), strings.HasPrefix(s[i:], "null"):
+			b.WriteString(colorLit + s[i:i+4] + colorReset)
+			i += 4
+		case strings.HasPrefix(s[i:], "false"):
+			b.WriteString(colorLit + s[i:i+5] + colorReset)
+			i += 5
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String()
+}
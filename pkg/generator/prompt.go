@@ -0,0 +1,230 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/sources"
+)
+
+var (
+	ExampleCount = 1
+)
+
+const promptTemplate = `Generate an AI prompt that can be used by professionals in the {{.Sector}} sector.
+
+Your task is to:
+- Create a practical and high-quality AI prompt relevant to the selected sector
+{{- if .Tone}}
+- Write the prompt and its description in a {{.Tone}} tone
+{{- end}}
+{{- if .Language}}
+- Write every value in {{.Language}}, keeping the JSON keys in English
+{{- end}}
+- Wrap your response in a clean JSON object with these keys:
+{{.Keys}}
+
+Output your response ONLY as a JSON object, without any extra commentary or Markdown.`
+
+const defaultKeysTemplate = `  - "title": Short, engaging name of the AI prompt
+  - "description": A brief explanation of what the AI prompt does and who it's for
+  - "tags": {{.TagMin}} to {{.TagMax}} lowercase tags (e.g. "marketing", "ecommerce", "email")
+  - "prompt": The actual AI prompt (what the user will copy and use)
+  - "useCases": A list of {{.UseCaseMin}}–{{.UseCaseMax}} specific use cases for this prompt
+{{if gt .ExampleCount 1}}  - "example": An array of {{.ExampleCount}} realistic examples of the output when this prompt is used{{else}}  - "example": A single realistic example of the output when this prompt is used{{end}}`
+
+type promptData struct {
+	Keys       string
+	Sector     string
+	TagMin     int
+	TagMax     int
+	UseCaseMin int
+	UseCaseMax int
+
+	ExampleCount int
+	Tone         string
+
+	// Language names the run's locale, e.g. "German", or is empty.
+	Language string
+
+	// ContentType and ContentDescription describe the run's content type,
+	// e.g. "newsletter issue", and are empty for AI prompts.
+	ContentType        string
+	ContentDescription string
+
+	// Headlines are recent items from the run's SOURCES, newest first.
+	Headlines []sources.Item
+
+	// Date is today as YYYY-MM-DD; Now allows other layouts, e.g.
+	// {{.Now.Format "Monday"}}.
+	Date string
+	Now  time.Time
+}
+
+var (
+	parsedPromptTemplate = template.Must(template.New("prompt").Parse(promptTemplate))
+	parsedKeysTemplate   = template.Must(template.New("keys").Parse(defaultKeysTemplate))
+
+	PromptTemplateDir = "templates"
+
+	// AvoidRecentTitles is how many recently sent titles to list in the
+	// prompt as ones to steer away from; zero disables the hint.
+	AvoidRecentTitles int
+
+	Tone string
+)
+
+var AllowedTones = []string{"professional", "casual", "playful", "friendly", "formal", "persuasive"}
+
+// ValidTone reports whether tone is one of AllowedTones.
+func ValidTone(tone string) bool {
+	for _, t := range AllowedTones {
+		if t == tone {
+			return true
+		}
+	}
+	return false
+}
+
+// sectorTemplate returns the template for the sector, preferring the job's
+// template, then <PROMPT_TEMPLATE_DIR>/<sector-slug>.tmpl, then
+// <PROMPT_TEMPLATE_DIR>/default.tmpl, over the built-in default, along with
+// the template source it was parsed from. A content type uses its own
+// template or the generic document one instead of the directory's.
+func sectorTemplate(ctx context.Context, sector string) (*template.Template, string, error) {
+	if j := JobFrom(ctx); j != nil && j.template != nil {
+		return j.template, j.templateSource, nil
+	}
+	if ct := contentTypeFrom(ctx); ct != nil {
+		if ct.template != nil {
+			return ct.template, ct.templateSource, nil
+		}
+		return parsedDocumentTemplate, documentTemplate, nil
+	}
+	if PromptTemplateDir == "" {
+		return parsedPromptTemplate, promptTemplate, nil
+	}
+
+	for _, name := range []string{slugify(sector), "default"} {
+		path := filepath.Join(PromptTemplateDir, name+".tmpl")
+		text, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		tmpl, err := template.New(filepath.Base(path)).Parse(string(text))
+		return tmpl, string(text), err
+	}
+	return parsedPromptTemplate, promptTemplate, nil
+}
+
+// templateVersion is a short content hash identifying a template revision.
+func templateVersion(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// buildPrompt renders the generation prompt for the sector and returns it
+// with the version of the template used.
+func buildPrompt(ctx context.Context, sector string) (string, string, error) {
+	tmpl, source, err := sectorTemplate(ctx, sector)
+	if err != nil {
+		return "", "", err
+	}
+
+	data := promptData{
+		Sector:     sector,
+		TagMin:     TagMin,
+		TagMax:     TagMax,
+		UseCaseMin: UseCaseMin,
+		UseCaseMax: UseCaseMax,
+
+		ExampleCount: ExampleCount,
+		Tone:         Tone,
+	}
+	if locale := LocaleFrom(ctx); locale != "" {
+		data.Language = languageName(locale)
+	}
+	data.Now = time.Now()
+	data.Date = data.Now.Format("2006-01-02")
+	if urls := sourcesFrom(ctx); len(urls) > 0 {
+		span := traceFrom(ctx).StartSpan("sources")
+		data.Headlines = sources.Recent(ctx, urls)
+		span.End(nil)
+		runctx.Logf(ctx, "📰 Grounding with %d headline(s) from %d source(s)", len(data.Headlines), len(urls))
+	}
+
+	var buf bytes.Buffer
+	if ct := contentTypeFrom(ctx); ct != nil {
+		data.ContentType = ct.label()
+		data.ContentDescription = ct.Schema.Description
+	}
+	if schema := SchemaFrom(ctx); schema != nil {
+		data.Keys = schema.keysInstructions()
+	} else {
+		if err := parsedKeysTemplate.Execute(&buf, data); err != nil {
+			return "", "", err
+		}
+		data.Keys = buf.String()
+		buf.Reset()
+	}
+
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", "", err
+	}
+	// Templates that place the headlines themselves get no extra block.
+	if len(data.Headlines) > 0 && !strings.Contains(source, ".Headlines") {
+		buf.WriteString("\n\nGround your response in these recent headlines where relevant, without copying them:\n")
+		for _, h := range data.Headlines {
+			buf.WriteString("- " + h.Title)
+			if h.Summary != "" {
+				buf.WriteString(": " + h.Summary)
+			}
+			if h.Source != "" {
+				buf.WriteString(" (" + h.Source + ")")
+			}
+			buf.WriteString("\n")
+		}
+	}
+	var titles []string
+	if AvoidRecentTitles > 0 && Seen != nil {
+		titles = Seen.RecentTitles(AvoidRecentTitles)
+	}
+	if titles = appendUnique(titles, avoidTitlesFrom(ctx)...); len(titles) > 0 {
+		buf.WriteString("\n\nAvoid generating anything similar to these recent titles:\n")
+		for _, t := range titles {
+			buf.WriteString("- " + t + "\n")
+		}
+	}
+	return buf.String(), templateVersion(source + data.Keys), nil
+}
+
+// appendUnique appends the non-empty items not already in list.
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		dup := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, item) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list = append(list, item)
+		}
+	}
+	return list
+}
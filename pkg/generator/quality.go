@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+const defaultReviewRubric = `You are an editor reviewing AI prompts before they are published to a prompt catalog.
+
+Score the prompt below from 1 to 10 using this rubric:
+- Usefulness: would a professional in the target sector actually use it?
+- Clarity: are the instructions specific and unambiguous?
+- Completeness: do the description, use cases and example support the prompt?
+- Originality: is it more than a generic, one-line request?
+
+Respond ONLY with a JSON object: {"score": <1-10>, "reason": "<one sentence>"}`
+
+var (
+	QualityReview    bool
+	QualityMinScore  = 6
+	ReviewPromptPath string
+
+	// QualityRegenAttempts is how many times a prompt scoring below
+	// QualityMinScore is regenerated before the run fails.
+	QualityRegenAttempts int
+
+	// QualityFailMode decides what happens when the review itself fails:
+	// "open" sends the prompt anyway, "closed" skips it.
+	QualityFailMode = "open"
+)
+
+// reviewRubric reads the rubric from REVIEW_PROMPT_PATH on every review so
+// editors can tune it without a restart, falling back to the built-in one.
+func reviewRubric() string {
+	if ReviewPromptPath == "" {
+		return defaultReviewRubric
+	}
+	data, err := os.ReadFile(ReviewPromptPath)
+	if err != nil || strings.TrimSpace(string(data)) == "" {
+		log.Printf("⚠️ Could not read review prompt %s, using the built-in rubric: %v", ReviewPromptPath, err)
+		return defaultReviewRubric
+	}
+	return string(data)
+}
+
+// reviewPrompt asks the model to score a generated prompt, or a content
+// type's document, against the review rubric.
+func reviewPrompt(ctx context.Context, p interface{}) (int, string, error) {
+	doc, _ := json.MarshalIndent(p, "", "  ")
+	content, err := llmFrom(ctx).Generate(ctx, reviewRubric()+"\n\nPrompt to review:\n"+string(doc))
+	if err != nil {
+		return 0, "", err
+	}
+
+	var verdict struct {
+		Score  int    `json:"score"`
+		Reason string `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(extractJSONBlock(ctx, content)), &verdict); err != nil {
+		return 0, "", fmt.Errorf("could not parse review: %w", err)
+	}
+	if verdict.Score < 1 || verdict.Score > 10 {
+		return 0, "", fmt.Errorf("review score %d out of range", verdict.Score)
+	}
+	return verdict.Score, verdict.Reason, nil
+}
+
+// checkQuality enforces QUALITY_MIN_SCORE when QUALITY_REVIEW is enabled.
+func checkQuality(ctx context.Context, p interface{}) error {
+	if !QualityReview {
+		return nil
+	}
+
+	span := traceFrom(ctx).StartSpan("quality.review")
+	score, reason, err := reviewPrompt(ctx, p)
+	span.End(err)
+	if err != nil {
+		if QualityFailMode == "closed" {
+			runctx.Logln(ctx, "⛔ Quality review unavailable, skipping (QUALITY_FAIL_MODE=closed):", err)
+			return fmt.Errorf("quality review unavailable: %w", err)
+		}
+		runctx.Logln(ctx, "⚠️ Quality review unavailable, sending anyway (QUALITY_FAIL_MODE=open):", err)
+		return nil
+	}
+
+	runctx.Logf(ctx, "🧑‍⚖️ Quality score %d/10: %s", score, reason)
+	if score < QualityMinScore {
+		return &lowScoreError{score: score, reason: reason}
+	}
+	return nil
+}
+
+// lowScoreError is a review scoring below QualityMinScore, as opposed to
+// the review itself failing.
+type lowScoreError struct {
+	score  int
+	reason string
+}
+
+func (e *lowScoreError) Error() string {
+	return fmt.Sprintf("quality score %d is below QUALITY_MIN_SCORE=%d: %s", e.score, QualityMinScore, e.reason)
+}
@@ -0,0 +1,457 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/approval"
+)
+
+// Schema is the subset of JSON Schema used to describe custom
+// output documents: top-level properties with a type, description and a
+// few constraints, plus the list of required fields. Title and Description
+// describe the document as a whole for content types.
+type Schema struct {
+	Title       string                    `json:"title"`
+	Description string                    `json:"description"`
+	Properties  map[string]schemaProperty `json:"properties"`
+	Required    []string                  `json:"required"`
+
+	order []string
+}
+
+type schemaProperty struct {
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Enum        []string `json:"enum"`
+	MaxLength   int      `json:"maxLength"`
+
+	// Items, MinItems and MaxItems constrain arrays.
+	Items    *schemaProperty `json:"items"`
+	MinItems int             `json:"minItems"`
+	MaxItems int             `json:"maxItems"`
+}
+
+var OutputSchema *Schema
+
+// LoadOutputSchema reads and checks the JSON Schema file at path.
+func LoadOutputSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if len(s.Properties) == 0 {
+		return nil, errors.New("schema has no properties")
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; !ok {
+			return nil, fmt.Errorf("required field %q is not a property", name)
+		}
+	}
+
+	// Keep the file's property order so the prompt lists keys as written.
+	var raw struct {
+		Properties json.RawMessage `json:"properties"`
+	}
+	json.Unmarshal(data, &raw)
+	dec := json.NewDecoder(bytes.NewReader(raw.Properties))
+	dec.Token()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		s.order = append(s.order, tok.(string))
+		var skip json.RawMessage
+		dec.Decode(&skip)
+	}
+	return &s, nil
+}
+
+// keysInstructions renders the schema as the bullet list of keys used in
+// the generation prompt.
+func (s *Schema) keysInstructions() string {
+	var b strings.Builder
+	for _, name := range s.order {
+		prop := s.Properties[name]
+		fmt.Fprintf(&b, "  - %q", name)
+		if prop.Type != "" {
+			fmt.Fprintf(&b, " (%s)", prop.Type)
+		}
+		if prop.Description != "" {
+			b.WriteString(": " + prop.Description)
+		}
+		if hint := prop.constraints(); hint != "" {
+			b.WriteString(" [" + hint + "]")
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func (s *Schema) validate(doc map[string]interface{}) error {
+	var problems []error
+	for _, name := range s.Required {
+		v, ok := doc[name]
+		if !ok || v == nil {
+			problems = append(problems, fmt.Errorf("%s: missing", name))
+			continue
+		}
+		if isEmptyValue(v) {
+			problems = append(problems, fmt.Errorf("%s: empty", name))
+		}
+	}
+	for _, name := range s.order {
+		v, ok := doc[name]
+		if !ok || v == nil {
+			continue
+		}
+		problems = append(problems, s.Properties[name].check(name, v)...)
+	}
+	return JoinProblems(problems)
+}
+
+// check validates one value against the property's type and constraints.
+func (p schemaProperty) check(name string, v interface{}) []error {
+	if p.Type != "" && !matchesType(v, p.Type) {
+		return []error{fmt.Errorf("%s: want %s", name, p.Type)}
+	}
+	var problems []error
+	switch t := v.(type) {
+	case string:
+		if len(p.Enum) > 0 && !containsString(p.Enum, t) {
+			problems = append(problems, fmt.Errorf("%s: %q is not one of %s", name, t, strings.Join(p.Enum, ", ")))
+		}
+		if p.MaxLength > 0 && utf8.RuneCountInString(t) > p.MaxLength {
+			problems = append(problems, fmt.Errorf("%s: longer than %d characters", name, p.MaxLength))
+		}
+	case []interface{}:
+		if p.MinItems > 0 && len(t) < p.MinItems {
+			problems = append(problems, fmt.Errorf("%s: want at least %d items, got %d", name, p.MinItems, len(t)))
+		}
+		if p.MaxItems > 0 && len(t) > p.MaxItems {
+			problems = append(problems, fmt.Errorf("%s: want at most %d items, got %d", name, p.MaxItems, len(t)))
+		}
+		if p.Items != nil {
+			for i, item := range t {
+				problems = append(problems, p.Items.check(fmt.Sprintf("%s[%d]", name, i), item)...)
+			}
+		}
+	}
+	return problems
+}
+
+// constraints summarises the property's constraints for the prompt.
+func (p schemaProperty) constraints() string {
+	var hints []string
+	if len(p.Enum) > 0 {
+		hints = append(hints, "one of "+strings.Join(p.Enum, ", "))
+	}
+	if p.MaxLength > 0 {
+		hints = append(hints, fmt.Sprintf("at most %d characters", p.MaxLength))
+	}
+	switch {
+	case p.MinItems > 0 && p.MaxItems > 0:
+		hints = append(hints, fmt.Sprintf("%d to %d items", p.MinItems, p.MaxItems))
+	case p.MinItems > 0:
+		hints = append(hints, fmt.Sprintf("at least %d items", p.MinItems))
+	case p.MaxItems > 0:
+		hints = append(hints, fmt.Sprintf("at most %d items", p.MaxItems))
+	}
+	if p.Items != nil && p.Items.Type != "" {
+		item := "each a " + p.Items.Type
+		if p.Items.Description != "" {
+			item += ": " + p.Items.Description
+		}
+		hints = append(hints, item)
+	}
+	return strings.Join(hints, "; ")
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isEmptyValue(v interface{}) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.TrimSpace(t) == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+func matchesType(v interface{}, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return true
+}
+
+// generateAndSendDocument is the content type and OUTPUT_SCHEMA_PATH
+// counterpart of the PromptResponse pipeline: the model output is kept as
+// a generic map validated against the run's schema, then goes through the
+// same moderation, duplicate and quality checks, header image and backup
+// before it is sent.
+func generateAndSendDocument(ctx context.Context, sector string) error {
+	doc, raw, version, err := generateUniqueDocument(ctx, sector)
+	var extractErr *extractionError
+	if errors.As(err, &extractErr) {
+		handleExtractionFailure(ctx, sector, extractErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	if DryRun {
+		return printJSON(doc, Pretty)
+	}
+
+	entry := documentEntry(SchemaFrom(ctx), doc, sector)
+	doc["templateVersion"] = version
+	if ct := contentTypeFrom(ctx); ct != nil {
+		doc["contentType"] = ct.Name
+	}
+	if locale := LocaleFrom(ctx); locale != "" {
+		doc["language"] = locale
+	}
+	if err = attachDocumentImage(ctx, doc, entry); err != nil {
+		return err
+	}
+	backupDocument(ctx, doc)
+	storeRaw(ctx, doc, sector, raw)
+
+	// As for prompts, the duplicate check is repeated under SendMu.
+	SendMu.Lock()
+	defer SendMu.Unlock()
+	if match, _, dup := Seen.FindSimilar(entry); dup {
+		runctx.Logf(ctx, "⏭️ %q duplicates %q sent by a concurrent run, skipping", entry.Title, match.Title)
+		return InStage("dedup", ErrDuplicate)
+	}
+
+	sendSpan := traceFrom(ctx).StartSpan("backend.send")
+	err = sendPayload(ctx, doc, &heldEntry{PromptResponse: entry})
+	sendSpan.End(err)
+	if err != nil && !Delivered(err) {
+		runctx.Logln(ctx, "❌ Failed to send prompt:", err)
+		return InStage("backend", err)
+	}
+	if approval.Enabled {
+		return nil
+	}
+
+	MarkSent(ctx, entry)
+	if err != nil {
+		runctx.Logln(ctx, "⚠️ Document saved, but some outputs failed and will be replayed:", err)
+		return err
+	}
+	runctx.Logln(ctx, "✅ Document saved successfully!")
+	return nil
+}
+
+// generateUniqueDocument is generateUnique for documents: it regenerates
+// on moderation hits, duplicates and low quality scores as configured.
+func generateUniqueDocument(ctx context.Context, sector string) (map[string]interface{}, string, string, error) {
+	schema := SchemaFrom(ctx)
+	regens, moderationRegens, qualityRegens := 0, 0, 0
+	for {
+		doc, raw, version, err := generateDocument(ctx, sector)
+		if err != nil {
+			return nil, "", "", err
+		}
+		entry := documentEntry(schema, doc, sector)
+
+		if err := checkDocumentContent(schema, doc); err != nil {
+			if ModerationAction != "regenerate" || moderationRegens >= ModerationRegenAttempts {
+				runctx.Logln(ctx, "🛡️", err)
+				return nil, "", "", InStage("moderation", err)
+			}
+			moderationRegens++
+			runctx.Logf(ctx, "🛡️ %v, regenerating (%d/%d)", err, moderationRegens, ModerationRegenAttempts)
+			ctx = withAvoidTitles(ctx, entry.Title)
+			continue
+		}
+
+		match, score, dup := Seen.FindSimilar(entry)
+		if !dup {
+			err := checkQuality(ctx, doc)
+			var low *lowScoreError
+			if err == nil {
+				return doc, raw, version, nil
+			}
+			if !errors.As(err, &low) || qualityRegens >= QualityRegenAttempts {
+				runctx.Logln(ctx, "❌ Generated document failed the quality review:", err)
+				return nil, "", "", InStage("quality", err)
+			}
+			qualityRegens++
+			runctx.Logf(ctx, "🧑‍⚖️ %v, regenerating (%d/%d)", err, qualityRegens, QualityRegenAttempts)
+			ctx = withAvoidTitles(ctx, entry.Title)
+			continue
+		}
+		if regens >= DedupRegenAttempts {
+			runctx.Logf(ctx, "⏭️ Still a duplicate of %q after %d regeneration(s), skipping", match.Title, regens)
+			return nil, "", "", InStage("dedup", ErrDuplicate)
+		}
+		regens++
+		runctx.Logf(ctx, "♻️ %q is too similar to %q (%.2f), regenerating (%d/%d)", entry.Title, match.Title, score, regens, DedupRegenAttempts)
+		ctx = withAvoidTitles(ctx, match.Title, entry.Title)
+	}
+}
+
+// generateDocument asks the model for a document valid against the run's
+// schema, re-asking for malformed or invalid output like generatePrompt.
+func generateDocument(ctx context.Context, sector string) (map[string]interface{}, string, string, error) {
+	schema := SchemaFrom(ctx)
+	prompt, version, err := buildPrompt(ctx, sector)
+	if err != nil {
+		runctx.Logln(ctx, "❌ Failed to build generation prompt:", err)
+		return nil, "", "", InStage("prompt", err)
+	}
+
+	var doc map[string]interface{}
+	var raw string
+	err = withReasks(ctx, prompt, func(ask string) (string, error) {
+		doc = nil
+		var err error
+		if raw, err = fetchJSON(ctx, ask, &doc); err != nil {
+			return "", err
+		}
+		validateSpan := traceFrom(ctx).StartSpan("validate")
+		err = schema.validate(doc)
+		validateSpan.End(err)
+		if err != nil {
+			runctx.Logln(ctx, "❌ Generated document failed validation:", err)
+			return raw, InStage("validation", err)
+		}
+		return raw, nil
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	return doc, raw, version, nil
+}
+
+// documentEntry describes a document for the dedup store: its title-like
+// field and the text of all its string values.
+func documentEntry(s *Schema, doc map[string]interface{}, sector string) PromptResponse {
+	p := PromptResponse{Sector: sector}
+	for _, key := range []string{"title", "subject", "headline", "name"} {
+		if t, ok := doc[key].(string); ok && strings.TrimSpace(t) != "" {
+			p.Title = strings.TrimSpace(t)
+			break
+		}
+	}
+	var text []string
+	s.walkStrings(doc, func(_, v string) bool {
+		text = append(text, v)
+		return true
+	})
+	p.Prompt = strings.Join(text, "\n")
+	return p
+}
+
+// checkDocumentContent applies the moderation rules to every string in
+// the document, nested ones included.
+func checkDocumentContent(s *Schema, doc map[string]interface{}) error {
+	for _, rule := range ContentRules {
+		var err error
+		s.walkStrings(doc, func(path, v string) bool {
+			if m := rule.find(v); m != "" {
+				err = fmt.Errorf("%s contains %s (%q)", path, rule.desc, m)
+				return false
+			}
+			return true
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkStrings calls fn with the path and value of each string in doc, in
+// schema order and then any keys the schema does not list, until fn
+// returns false.
+func (s *Schema) walkStrings(doc map[string]interface{}, fn func(path, v string) bool) {
+	for _, name := range s.order {
+		if v, ok := doc[name]; ok && !walkValue(name, v, fn) {
+			return
+		}
+	}
+	var extra []string
+	for name := range doc {
+		if _, ok := s.Properties[name]; !ok {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	for _, name := range extra {
+		if !walkValue(name, doc[name], fn) {
+			return
+		}
+	}
+}
+
+func walkValue(path string, v interface{}, fn func(path, v string) bool) bool {
+	switch t := v.(type) {
+	case string:
+		return fn(path, t)
+	case []interface{}:
+		for i, item := range t {
+			if !walkValue(fmt.Sprintf("%s[%d]", path, i), item, fn) {
+				return false
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if !walkValue(path+"."+k, t[k], fn) {
+				return false
+			}
+		}
+	}
+	return true
+}
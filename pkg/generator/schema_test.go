@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckDocumentContent(t *testing.T) {
+	schema := &Schema{
+		Properties: map[string]schemaProperty{"title": {Type: "string"}, "sections": {Type: "array"}},
+		order:      []string{"title", "sections"},
+	}
+	tests := []struct {
+		name string
+		doc  map[string]interface{}
+		want string
+	}{
+		{
+			name: "clean document",
+			doc:  map[string]interface{}{"title": "Launch plan", "sections": []interface{}{"Goals"}},
+		},
+		{
+			name: "top-level field",
+			doc:  map[string]interface{}{"title": "A spam launch"},
+			want: "title contains",
+		},
+		{
+			name: "string nested in an array of objects",
+			doc: map[string]interface{}{
+				"title":    "Launch plan",
+				"sections": []interface{}{map[string]interface{}{"body": "Buy SPAM now"}},
+			},
+			want: "sections[0].body contains",
+		},
+		{
+			name: "field the schema does not list",
+			doc:  map[string]interface{}{"title": "Launch plan", "footer": "spam"},
+			want: "footer contains",
+		},
+	}
+	defer func(rules []ContentRule) { ContentRules = rules }(ContentRules)
+	ContentRules = []ContentRule{BannedWordRule("spam")}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkDocumentContent(schema, tt.doc)
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("checkDocumentContent() = %v, want nil", err)
+			case tt.want != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.want)):
+				t.Errorf("checkDocumentContent() = %v, want %q...", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchesType(t *testing.T) {
+	tests := []struct {
+		v    interface{}
+		typ  string
+		want bool
+	}{
+		{3.0, "integer", true},
+		{-2.0, "integer", true},
+		{2.5, "integer", false},
+		{2.5, "number", true},
+		{"3", "integer", false},
+		{"x", "string", true},
+		{[]interface{}{}, "array", true},
+		{map[string]interface{}{}, "object", true},
+		{true, "boolean", true},
+	}
+	for _, tt := range tests {
+		if got := matchesType(tt.v, tt.typ); got != tt.want {
+			t.Errorf("matchesType(%#v, %q) = %v, want %v", tt.v, tt.typ, got, tt.want)
+		}
+	}
+}
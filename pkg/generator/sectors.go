@@ -0,0 +1,253 @@
+package generator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"promptcraft-groq/internal/state"
+)
+
+var Sectors = []string{
+	"marketing",
+	"education",
+	"finance",
+	"healthcare",
+	"e-commerce",
+	"SaaS",
+	"real estate",
+	"coaching",
+	"content creation",
+}
+
+type SectorWeight struct {
+	sector string
+	weight int
+}
+
+var (
+	SectorWeights []SectorWeight
+	// SectorOrder is "random" (uniform or weighted) or "round-robin".
+	SectorOrder = "random"
+
+	sectorMu   sync.Mutex
+	sectorRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	sectorNext int
+)
+
+// ParseSectors parses a comma-separated SECTORS list.
+func ParseSectors(spec string) []string {
+	var out []string
+	for _, s := range strings.Split(spec, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// SeedSectorRand makes sector selection deterministic, e.g. for tests.
+func SeedSectorRand(seed int64) {
+	sectorMu.Lock()
+	defer sectorMu.Unlock()
+	sectorRand = rand.New(rand.NewSource(seed))
+}
+
+// ParseSectorWeights parses "marketing=5,real estate=1".
+func ParseSectorWeights(spec string) ([]SectorWeight, error) {
+	var out []SectorWeight
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, w, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want sector=weight", part)
+		}
+		weight, err := strconv.Atoi(strings.TrimSpace(w))
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("%q: weight must be a non-negative integer", part)
+		}
+		out = append(out, SectorWeight{strings.TrimSpace(name), weight})
+	}
+
+	total := 0
+	for _, sw := range out {
+		total += sw.weight
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("at least one sector needs a positive weight")
+	}
+	return out, nil
+}
+
+var errAllSectorsFull = errors.New("every sector has reached its SECTOR_TARGETS maximum")
+
+// pickSector chooses the next sector. Sectors at their catalog maximum are
+// skipped, and while any sector is below its minimum only those are
+// considered; otherwise the choice is uniform, by SECTOR_WEIGHTS, or the
+// next sector in turn with SECTOR_ORDER=round-robin.
+func pickSector() (string, error) {
+	candidates := SectorWeights
+	if len(candidates) == 0 {
+		for _, s := range Sectors {
+			candidates = append(candidates, SectorWeight{s, 1})
+		}
+	}
+
+	if len(SectorTargets) > 0 {
+		var open, under []SectorWeight
+		for _, sw := range candidates {
+			t, ok := SectorTargets[sw.sector]
+			n := SectorCounts.Get(sw.sector)
+			if ok && t.max > 0 && n >= t.max {
+				continue
+			}
+			open = append(open, sw)
+			if ok && n < t.min && sw.weight > 0 {
+				under = append(under, sw)
+			}
+		}
+		if len(under) > 0 {
+			open = under
+		}
+		candidates = open
+	}
+
+	total := 0
+	for _, sw := range candidates {
+		total += sw.weight
+	}
+	if total == 0 {
+		return "", errAllSectorsFull
+	}
+
+	if SectorOrder == "round-robin" {
+		var active []string
+		for _, sw := range candidates {
+			if sw.weight > 0 {
+				active = append(active, sw.sector)
+			}
+		}
+		sectorMu.Lock()
+		defer sectorMu.Unlock()
+		s := active[sectorNext%len(active)]
+		sectorNext++
+		return s, nil
+	}
+
+	sectorMu.Lock()
+	n := sectorRand.Intn(total)
+	sectorMu.Unlock()
+	for _, sw := range candidates {
+		if n < sw.weight {
+			return sw.sector, nil
+		}
+		n -= sw.weight
+	}
+	return candidates[len(candidates)-1].sector, nil
+}
+
+type SectorTarget struct {
+	min, max int
+}
+
+var SectorTargets map[string]SectorTarget
+
+// ParseSectorTargets parses "marketing=10:50,finance=5:" where each value
+// is min:max and either side may be omitted (a bare number is a minimum).
+func ParseSectorTargets(spec string) (map[string]SectorTarget, error) {
+	out := make(map[string]SectorTarget)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, rng, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("%q: want sector=min:max", part)
+		}
+		lo, hi, _ := strings.Cut(rng, ":")
+
+		var t SectorTarget
+		var err error
+		if lo = strings.TrimSpace(lo); lo != "" {
+			if t.min, err = strconv.Atoi(lo); err != nil || t.min < 0 {
+				return nil, fmt.Errorf("%q: invalid minimum", part)
+			}
+		}
+		if hi = strings.TrimSpace(hi); hi != "" {
+			if t.max, err = strconv.Atoi(hi); err != nil || t.max < 0 {
+				return nil, fmt.Errorf("%q: invalid maximum", part)
+			}
+		}
+		if t.max > 0 && t.min > t.max {
+			return nil, fmt.Errorf("%q: minimum exceeds maximum", part)
+		}
+		out[strings.TrimSpace(name)] = t
+	}
+	return out, nil
+}
+
+// SectorCounter tracks how many prompts have been sent per sector.
+type SectorCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+	file   *state.File
+}
+
+var SectorCounts = &SectorCounter{counts: map[string]int{}}
+
+// LoadSectorCounter reads the per-sector counts at path.
+func LoadSectorCounter(path string) *SectorCounter {
+	c := &SectorCounter{counts: map[string]int{}}
+	c.file = state.Register(path, func() ([]byte, error) {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return json.MarshalIndent(c.counts, "", "  ")
+	})
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &c.counts); err != nil {
+			log.Println("⚠️ Could not parse sector counts, starting empty:", err)
+			c.counts = map[string]int{}
+		}
+	} else if !os.IsNotExist(err) {
+		log.Println("⚠️ Could not read sector counts:", err)
+	}
+	return c
+}
+
+func (c *SectorCounter) Get(sector string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.counts[sector]
+}
+
+func (c *SectorCounter) Inc(sector string) {
+	c.mu.Lock()
+	c.counts[sector]++
+	c.mu.Unlock()
+	if c.file != nil {
+		c.file.MarkDirty()
+	}
+}
+
+// slugify turns a name into a lowercase, hyphenated identifier, e.g.
+// "Real Estate" -> "real-estate".
+func slugify(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"errors"
+
+	"promptcraft-groq/pkg/llm"
+)
+
+// stagedError tags an error with the pipeline stage it came from without
+// changing its message.
+type stagedError struct {
+	stage string
+	err   error
+}
+
+func (e *stagedError) Error() string { return e.err.Error() }
+
+func (e *stagedError) Unwrap() error { return e.err }
+
+// InStage tags err with the stage it came from, for FailureStage.
+func InStage(stage string, err error) error {
+	return &stagedError{stage: stage, err: err}
+}
+
+// UnsentError is returned by SendPayload when a sink rejected the payload.
+// It keeps the payload and the sinks that rejected it so the run history
+// can replay it to just those. Sent means the primary sink took it, so the
+// run counts as sent.
+type UnsentError struct {
+	Payload []byte
+	Sinks   []string
+	Sent    bool
+	Err     error
+}
+
+func (e *UnsentError) Error() string { return e.Err.Error() }
+
+func (e *UnsentError) Unwrap() error { return e.Err }
+
+// UnsentPayload returns the payload err carries, or nil.
+func UnsentPayload(err error) []byte {
+	var unsent *UnsentError
+	if errors.As(err, &unsent) {
+		return unsent.Payload
+	}
+	return nil
+}
+
+// UnsentSinks returns the sinks err says still need the payload, or nil
+// for all of them.
+func UnsentSinks(err error) []string {
+	var unsent *UnsentError
+	if errors.As(err, &unsent) {
+		return unsent.Sinks
+	}
+	return nil
+}
+
+// Delivered reports whether err still counts as sent: nil, or only
+// secondary sinks failed.
+func Delivered(err error) bool {
+	var unsent *UnsentError
+	return err == nil || errors.As(err, &unsent) && unsent.Sent
+}
+
+// FailureStage names the stage a run failed in. Running out of token
+// budget counts as "budget" wherever it happened.
+func FailureStage(err error) string {
+	var staged *stagedError
+	var extractErr *extractionError
+	var budgetErr *llm.BudgetError
+	switch {
+	case errors.As(err, &budgetErr):
+		return "budget"
+	case errors.As(err, &staged):
+		return staged.stage
+	case errors.As(err, &extractErr):
+		return "extraction"
+	}
+	return "unknown"
+}
@@ -0,0 +1,133 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	TagFallback = "fail"
+)
+
+var titleStopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "the": true, "for": true, "with": true,
+	"your": true, "you": true, "from": true, "into": true, "that": true,
+	"this": true, "how": true, "what": true, "ai": true, "prompt": true,
+}
+
+// TagCanonical rewrites known tag variants to their canonical form, e.g.
+// "e-commerce" -> "ecommerce".
+var TagCanonical map[string]string
+
+// ParseTagCanonical parses "e-commerce=ecommerce,ml=machine-learning".
+// Chained mappings such as "a=b,b=c" resolve to the final form; cycles are
+// rejected.
+func ParseTagCanonical(spec string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		variant, canonical, ok := strings.Cut(part, "=")
+		variant = strings.ToLower(strings.TrimSpace(variant))
+		canonical = strings.ToLower(strings.TrimSpace(canonical))
+		if !ok || variant == "" || canonical == "" {
+			return nil, fmt.Errorf("%q: want variant=canonical", part)
+		}
+		out[variant] = canonical
+	}
+
+	resolved := make(map[string]string, len(out))
+	for variant, canonical := range out {
+		visited := map[string]bool{variant: true}
+		for {
+			next, ok := out[canonical]
+			if !ok || next == canonical {
+				break
+			}
+			if visited[canonical] {
+				return nil, fmt.Errorf("%q: mappings form a cycle", variant)
+			}
+			visited[canonical] = true
+			canonical = next
+		}
+		resolved[variant] = canonical
+	}
+	return resolved, nil
+}
+
+func canonicalTag(ctx context.Context, t string) string {
+	if c, ok := TagCanonical[t]; ok && c != t {
+		runctx.Logf(ctx, "🏷️ Rewrote tag %q to %q", t, c)
+		return c
+	}
+	return t
+}
+
+// normalizeTags lowercases, trims and canonicalizes tags, dropping empties
+// and duplicates.
+func normalizeTags(ctx context.Context, tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		t = canonicalTag(ctx, strings.ToLower(strings.TrimSpace(t)))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// deriveTags tops up tags with the sector and prominent words from the
+// title until the configured minimum is met.
+func deriveTags(ctx context.Context, tags []string, sector, title string) []string {
+	out := normalizeTags(ctx, tags)
+	seen := make(map[string]bool, len(out))
+	for _, t := range out {
+		seen[t] = true
+	}
+
+	if slug := canonicalTag(ctx, slugify(sector)); slug != "" && !seen[slug] && len(out) < TagMin {
+		seen[slug] = true
+		out = append(out, slug)
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if len(out) >= TagMin {
+			break
+		}
+		if len(w) < 3 || titleStopwords[w] || seen[w] {
+			continue
+		}
+		seen[w] = true
+		out = append(out, w)
+	}
+	return out
+}
+
+func applyTagFallback(ctx context.Context, p *PromptResponse) {
+	if TagFallback != "derive" {
+		if len(TagCanonical) > 0 {
+			p.Tags = normalizeTags(ctx, p.Tags)
+		}
+		return
+	}
+	p.Tags = normalizeTags(ctx, p.Tags)
+	if len(p.Tags) >= TagMin {
+		return
+	}
+
+	before := len(p.Tags)
+	p.Tags = deriveTags(ctx, p.Tags, p.Sector, p.Title)
+	runctx.Logf(ctx, "🏷️ Derived %d tag(s) from sector and title: %v", len(p.Tags)-before, p.Tags)
+}
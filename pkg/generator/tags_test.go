@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTagCanonical(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{spec: "E-Commerce = ecommerce, ml=machine-learning", want: map[string]string{"e-commerce": "ecommerce", "ml": "machine-learning"}},
+		{spec: "a=b,b=c", want: map[string]string{"a": "c", "b": "c"}},
+		{spec: "a=b,b=c,c=c", want: map[string]string{"a": "c", "b": "c", "c": "c"}},
+		{spec: "a=b,b=a", wantErr: true},
+		{spec: "a=b,b=c,c=a", wantErr: true},
+		{spec: "a", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTagCanonical(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseTagCanonical(%q) = %v, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseTagCanonical(%q): %v", tt.spec, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseTagCanonical(%q) = %v, want %v", tt.spec, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const titlesPromptTemplate = `Suggest %d short, engaging titles for AI prompts that professionals in the %s sector would use.
+
+Output ONLY a JSON array of strings, without any extra commentary or Markdown.`
+
+var (
+	jsonArrayRe  = regexp.MustCompile(`(?s)\[.*\]`)
+	listPrefixRe = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*•])\s*`)
+)
+
+// GenerateTitles asks the model for n candidate titles for a sector.
+func GenerateTitles(ctx context.Context, sector string, n int) ([]string, error) {
+	content, err := LLM.Generate(ctx, fmt.Sprintf(titlesPromptTemplate, n, sector))
+	if err != nil {
+		return nil, err
+	}
+
+	var titles []string
+	if match := jsonArrayRe.FindString(content); match == "" || json.Unmarshal([]byte(match), &titles) != nil {
+		// Not a JSON array; treat each non-empty line as a title.
+		titles = nil
+		for _, line := range strings.Split(content, "\n") {
+			line = strings.Trim(listPrefixRe.ReplaceAllString(line, ""), " \"")
+			if line != "" {
+				titles = append(titles, line)
+			}
+		}
+	}
+
+	if len(titles) > n {
+		titles = titles[:n]
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("no titles found in model response")
+	}
+	return titles, nil
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"promptcraft-groq/pkg/llm"
+)
+
+// StructuredOutput forces the model to answer with an object: "json" uses
+// the provider's JSON mode and "tools" a tool call. Either falls back to
+// extracting the JSON from free text when the provider lacks it or the
+// call fails; "off" always extracts.
+var StructuredOutput = "off"
+
+const promptToolName = "save_prompt"
+
+func promptToolSchema() map[string]interface{} {
+	example := map[string]interface{}{"type": "object", "description": "A realistic example of the output"}
+	if ExampleCount > 1 {
+		example = map[string]interface{}{
+			"type":        "array",
+			"items":       map[string]interface{}{"type": "object"},
+			"description": fmt.Sprintf("%d realistic examples of the output", ExampleCount),
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"title":       map[string]interface{}{"type": "string", "description": "Short, engaging name of the AI prompt"},
+			"description": map[string]interface{}{"type": "string", "description": "What the AI prompt does and who it's for"},
+			"prompt":      map[string]interface{}{"type": "string", "description": "The actual AI prompt the user will copy and use"},
+			"useCases": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"tags": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+			"example": example,
+		},
+		"required": []string{"title", "description", "prompt", "useCases", "tags", "example"},
+	}
+}
+
+// getPromptViaToolCall asks the model to call a single tool whose arguments
+// match PromptResponse and returns the raw JSON arguments.
+func getPromptViaToolCall(ctx context.Context, userPrompt string) (string, error) {
+	provider := llmFrom(ctx)
+	requestBody := map[string]interface{}{
+		"model": provider.Model(),
+		"messages": []map[string]string{
+			{"role": "user", "content": userPrompt},
+		},
+		"tools": []map[string]interface{}{
+			{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        promptToolName,
+					"description": "Save the generated AI prompt",
+					"parameters":  promptToolSchema(),
+				},
+			},
+		},
+		"tool_choice": map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": promptToolName},
+		},
+	}
+
+	completer, ok := provider.(llm.ChatCompleter)
+	if !ok {
+		return "", fmt.Errorf("%s does not support tool calling", provider.Name())
+	}
+	result, err := completer.Complete(ctx, requestBody)
+	if err != nil {
+		return "", err
+	}
+
+	for _, call := range result.Choices[0].Message.ToolCalls {
+		if call.Function.Name == promptToolName && strings.TrimSpace(call.Function.Arguments) != "" {
+			return call.Function.Arguments, nil
+		}
+	}
+	return "", fmt.Errorf("model did not call %s", promptToolName)
+}
@@ -0,0 +1,207 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// A minimal OTLP/HTTP (JSON) trace exporter. Every generation run becomes
+// one trace with a root span and a child span per stage. All methods are
+// no-ops on a nil *runTrace, which is what startTrace returns when
+// OTEL_EXPORTER_OTLP_ENDPOINT is unset.
+
+var (
+	OTLPEndpoint   string
+	OTELService    = "autopost"
+	otlpHTTPClient = &http.Client{Timeout: 5 * time.Second}
+)
+
+// legacySpanNames are the names spans had before they were made
+// provider-neutral. Each such span is exported under both names until
+// dashboards have moved over.
+var legacySpanNames = map[string]string{"llm.call": "groq.call"}
+
+type span struct {
+	trace    *runTrace
+	id       string
+	parentID string
+	name     string
+	start    time.Time
+	end      time.Time
+	attrs    map[string]string
+	err      error
+}
+
+type runTrace struct {
+	mu    sync.Mutex
+	id    string
+	root  *span
+	spans []*span
+}
+
+func startTrace(ctx context.Context, name string) *runTrace {
+	if OTLPEndpoint == "" {
+		return nil
+	}
+	t := &runTrace{id: runctx.RandomHex(16)}
+	t.root = &span{trace: t, id: runctx.RandomHex(8), name: name, start: time.Now(), attrs: map[string]string{}}
+	t.spans = append(t.spans, t.root)
+	provider := llmFrom(ctx)
+	t.SetAttr("provider", strings.ToLower(provider.Name()))
+	t.SetAttr("model", provider.Model())
+	if job := runctx.JobName(ctx); job != "" {
+		t.SetAttr("job", job)
+	}
+	return t
+}
+
+// SetAttr sets an attribute on the root span.
+func (t *runTrace) SetAttr(key, value string) {
+	if t == nil {
+		return
+	}
+	t.root.SetAttr(key, value)
+}
+
+// StartSpan starts a stage span as a child of the root span.
+func (t *runTrace) StartSpan(name string) *span {
+	if t == nil {
+		return nil
+	}
+	s := &span{trace: t, id: runctx.RandomHex(8), parentID: t.root.id, name: name, start: time.Now(), attrs: map[string]string{}}
+	t.mu.Lock()
+	for _, k := range []string{"provider", "model", "sector"} {
+		if v, ok := t.root.attrs[k]; ok {
+			s.attrs[k] = v
+		}
+	}
+	t.spans = append(t.spans, s)
+	t.mu.Unlock()
+	return s
+}
+
+func (s *span) SetAttr(key, value string) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	s.attrs[key] = value
+	s.trace.mu.Unlock()
+}
+
+func (s *span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.trace.mu.Lock()
+	s.end = time.Now()
+	s.err = err
+	if _, ok := s.attrs["status"]; !ok {
+		s.attrs["status"] = "success"
+		if err != nil {
+			s.attrs["status"] = "failed"
+		}
+	}
+	s.trace.mu.Unlock()
+}
+
+// Finish ends the root span and exports the whole trace.
+func (t *runTrace) Finish(err error) {
+	if t == nil {
+		return
+	}
+	t.root.End(err)
+
+	if err := exportTrace(t); err != nil {
+		log.Println("⚠️ Failed to export trace:", err)
+	}
+}
+
+func otlpString(key, value string) map[string]interface{} {
+	return map[string]interface{}{"key": key, "value": map[string]string{"stringValue": value}}
+}
+
+func exportTrace(t *runTrace) error {
+	t.mu.Lock()
+	spans := make([]map[string]interface{}, 0, len(t.spans))
+	for _, s := range t.spans {
+		end := s.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		attrs := make([]map[string]interface{}, 0, len(s.attrs))
+		for k, v := range s.attrs {
+			attrs = append(attrs, otlpString(k, v))
+		}
+		status := map[string]interface{}{"code": 1}
+		if s.err != nil {
+			status = map[string]interface{}{"code": 2, "message": s.err.Error()}
+		}
+		otlpSpan := func(id, name string) map[string]interface{} {
+			return map[string]interface{}{
+				"traceId":           t.id,
+				"spanId":            id,
+				"parentSpanId":      s.parentID,
+				"name":              name,
+				"kind":              1,
+				"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+				"endTimeUnixNano":   strconv.FormatInt(end.UnixNano(), 10),
+				"attributes":        attrs,
+				"status":            status,
+			}
+		}
+		spans = append(spans, otlpSpan(s.id, s.name))
+		if legacy, ok := legacySpanNames[s.name]; ok {
+			spans = append(spans, otlpSpan(runctx.RandomHex(8), legacy))
+		}
+	}
+	t.mu.Unlock()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []map[string]interface{}{{
+			"resource": map[string]interface{}{
+				"attributes": []map[string]interface{}{otlpString("service.name", OTELService)},
+			},
+			"scopeSpans": []map[string]interface{}{{
+				"scope": map[string]string{"name": "autopost"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimRight(OTLPEndpoint, "/") + "/v1/traces"
+	resp, err := otlpHTTPClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+type traceKey struct{}
+
+func withTrace(ctx context.Context, t *runTrace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// traceFrom returns the run's trace, or nil (a no-op trace) if none.
+func traceFrom(ctx context.Context) *runTrace {
+	t, _ := ctx.Value(traceKey{}).(*runTrace)
+	return t
+}
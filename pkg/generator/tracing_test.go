@@ -0,0 +1,52 @@
+package generator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestExportTraceLegacySpanNames(t *testing.T) {
+	var names []string
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct {
+						Name string `json:"name"`
+					} `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, s := range body.ResourceSpans[0].ScopeSpans[0].Spans {
+			names = append(names, s.Name)
+		}
+	}))
+	defer collector.Close()
+	defer func(endpoint string) { OTLPEndpoint = endpoint }(OTLPEndpoint)
+	OTLPEndpoint = collector.URL
+
+	tr := &runTrace{id: "0123456789abcdef0123456789abcdef"}
+	tr.root = &span{trace: tr, id: "0123456789abcdef", name: "generate", start: time.Now(), attrs: map[string]string{}}
+	tr.spans = append(tr.spans, tr.root)
+	tr.StartSpan("llm.call").End(nil)
+	tr.StartSpan("validate").End(nil)
+	if err := exportTrace(tr); err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(names)
+	want := []string{"generate", "groq.call", "llm.call", "validate"}
+	if len(names) != len(want) {
+		t.Fatalf("exported spans %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("exported spans %v, want %v", names, want)
+		}
+	}
+}
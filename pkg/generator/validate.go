@@ -0,0 +1,245 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	TagMin     = 3
+	TagMax     = 5
+	UseCaseMin = 3
+	UseCaseMax = 5
+)
+
+var (
+	TitleAllowRegex    *regexp.Regexp
+	TitleDenyRegex     *regexp.Regexp
+	TitleRuleAction    = "fail"
+	TitleRegenAttempts = 1
+)
+
+// checkTitleRules enforces TITLE_ALLOW_REGEX and TITLE_DENY_REGEX.
+func checkTitleRules(title string) error {
+	if TitleAllowRegex != nil && !TitleAllowRegex.MatchString(title) {
+		return fmt.Errorf("title %q does not match TITLE_ALLOW_REGEX %s", title, TitleAllowRegex)
+	}
+	if TitleDenyRegex != nil {
+		if m := TitleDenyRegex.FindString(title); m != "" {
+			return fmt.Errorf("title %q matches TITLE_DENY_REGEX %s (%q)", title, TitleDenyRegex, m)
+		}
+	}
+	return nil
+}
+
+var (
+	PlaceholderPatterns      []*regexp.Regexp
+	PlaceholderAction        = "fail"
+	PlaceholderRegenAttempts = 1
+)
+
+// checkPlaceholders rejects prompts whose prompt or description still
+// contain leftover placeholder text such as "[INSERT TOPIC]".
+func checkPlaceholders(p PromptResponse) error {
+	for _, re := range PlaceholderPatterns {
+		for _, field := range []struct{ name, text string }{{"prompt", p.Prompt}, {"description", p.Description}} {
+			if m := re.FindString(field.text); m != "" {
+				return fmt.Errorf("%s contains placeholder %q (pattern %s)", field.name, m, re)
+			}
+		}
+	}
+	return nil
+}
+
+// ContentRule is one entry of BANNED_WORDS or CONTENT_DENY_PATTERNS.
+type ContentRule struct {
+	desc string
+	re   *regexp.Regexp
+	// group is the submatch holding the offending text, 0 for all of it.
+	group int
+}
+
+// find returns the text the rule matches in s, or "".
+func (r ContentRule) find(s string) string {
+	m := r.re.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	return m[r.group]
+}
+
+var (
+	ContentRules            []ContentRule
+	DescriptionMinLength    int
+	PromptMinLength         int
+	ModerationAction        = "fail"
+	ModerationRegenAttempts = 1
+)
+
+// BannedWordRule matches word as a whole word, ignoring case. A side of
+// the word that ends in punctuation, as in "c++" or "@handle", is bounded
+// by the start or end of the text or a non-word character instead of \b,
+// which would never match there.
+func BannedWordRule(word string) ContentRule {
+	pattern := "(" + regexp.QuoteMeta(word) + ")"
+	if first, _ := utf8.DecodeRuneInString(word); isWordRune(first) {
+		pattern = `\b` + pattern
+	} else {
+		pattern = `(?:^|\W)` + pattern
+	}
+	if last, _ := utf8.DecodeLastRuneInString(word); isWordRune(last) {
+		pattern += `\b`
+	} else {
+		pattern += `(?:\W|$)`
+	}
+	return ContentRule{
+		desc:  fmt.Sprintf("banned word %q", word),
+		re:    regexp.MustCompile(`(?i)` + pattern),
+		group: 1,
+	}
+}
+
+// isWordRune reports whether \b treats r as a word character.
+func isWordRune(r rune) bool {
+	return r == '_' || ('0' <= r && r <= '9') || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+// PatternRule matches re, a CONTENT_DENY_PATTERNS entry.
+func PatternRule(re *regexp.Regexp) ContentRule {
+	return ContentRule{desc: "denied pattern " + re.String(), re: re}
+}
+
+// checkContent applies the moderation rules: BANNED_WORDS and
+// CONTENT_DENY_PATTERNS over every text field, then the minimum
+// description and prompt lengths.
+func checkContent(p PromptResponse) error {
+	fields := []struct{ name, text string }{
+		{"title", p.Title},
+		{"description", p.Description},
+		{"prompt", p.Prompt},
+		{"useCases", strings.Join(p.UseCases, "\n")},
+		{"tags", strings.Join(p.Tags, " ")},
+	}
+	for _, rule := range ContentRules {
+		for _, f := range fields {
+			if m := rule.find(f.text); m != "" {
+				return fmt.Errorf("%s contains %s (%q)", f.name, rule.desc, m)
+			}
+		}
+	}
+
+	if n := utf8.RuneCountInString(strings.TrimSpace(p.Description)); n < DescriptionMinLength {
+		return fmt.Errorf("description is %d characters, below DESCRIPTION_MIN_LENGTH=%d", n, DescriptionMinLength)
+	}
+	if n := utf8.RuneCountInString(strings.TrimSpace(p.Prompt)); n < PromptMinLength {
+		return fmt.Errorf("prompt is %d characters, below PROMPT_MIN_LENGTH=%d", n, PromptMinLength)
+	}
+	return nil
+}
+
+// JoinProblems combines validation failures into one single-line error.
+func JoinProblems(problems []error) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	msgs := make([]string, len(problems))
+	for i, p := range problems {
+		msgs[i] = p.Error()
+	}
+	return errors.New(strings.Join(msgs, "; "))
+}
+
+// dropEmptyUseCases removes blank use cases so they neither reach the
+// frontend nor count towards the configured range.
+func dropEmptyUseCases(ctx context.Context, p *PromptResponse) {
+	cleaned := p.UseCases[:0]
+	for _, uc := range p.UseCases {
+		if strings.TrimSpace(uc) != "" {
+			cleaned = append(cleaned, uc)
+		}
+	}
+	if removed := len(p.UseCases) - len(cleaned); removed > 0 {
+		runctx.Logf(ctx, "🧹 Dropped %d empty use case(s)", removed)
+	}
+	p.UseCases = cleaned
+}
+
+// decodeExample turns the raw "example" value into an object, or a list of
+// objects when EXAMPLE_COUNT > 1. Anything that isn't an object is kept
+// under a "text" key.
+func decodeExample(raw json.RawMessage) interface{} {
+	if ExampleCount > 1 {
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err == nil {
+			out := make([]interface{}, 0, len(items))
+			for _, item := range items {
+				out = append(out, decodeExampleObject(item))
+			}
+			return out
+		}
+	}
+	return decodeExampleObject(raw)
+}
+
+func decodeExampleObject(raw json.RawMessage) map[string]interface{} {
+	var example map[string]interface{}
+	if len(raw) > 0 && raw[0] == '{' {
+		if err := json.Unmarshal(raw, &example); err != nil {
+			example = map[string]interface{}{"text": string(raw)}
+		}
+	} else {
+		example = map[string]interface{}{"text": string(raw)}
+	}
+	return example
+}
+
+var (
+	SanitizeExample  bool
+	ExampleMaxLength int
+)
+
+// sanitizeExample walks the example's maps and arrays, trimming strings,
+// stripping control characters other than newlines and tabs, and capping
+// them at ExampleMaxLength runes when set.
+func sanitizeExample(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = sanitizeExample(item)
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeExample(item)
+		}
+		return v
+	case string:
+		return sanitizeString(v)
+	default:
+		return v
+	}
+}
+
+func sanitizeString(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\n' && r != '\t' {
+			return -1
+		}
+		return r
+	}, s)
+	s = strings.TrimSpace(s)
+	if ExampleMaxLength > 0 {
+		if runes := []rune(s); len(runes) > ExampleMaxLength {
+			s = strings.TrimSpace(string(runes[:ExampleMaxLength]))
+		}
+	}
+	return s
+}
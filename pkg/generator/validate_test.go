@@ -0,0 +1,82 @@
+package generator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeExample(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxLen int
+		in     interface{}
+		want   interface{}
+	}{
+		{
+			name: "nested maps",
+			in: map[string]interface{}{
+				"input":  map[string]interface{}{"topic": "  launch\x00 ", "count": 3.0},
+				"output": "done\x07",
+			},
+			want: map[string]interface{}{
+				"input":  map[string]interface{}{"topic": "launch", "count": 3.0},
+				"output": "done",
+			},
+		},
+		{
+			name: "arrays of maps and strings",
+			in: []interface{}{
+				map[string]interface{}{"lines": []interface{}{" a\x1b", "b\nc\t"}},
+				" x ",
+				true,
+			},
+			want: []interface{}{
+				map[string]interface{}{"lines": []interface{}{"a", "b\nc"}},
+				"x",
+				true,
+			},
+		},
+		{
+			name:   "length cap in nested values",
+			maxLen: 5,
+			in:     map[string]interface{}{"deep": []interface{}{map[string]interface{}{"text": "héllo world"}}},
+			want:   map[string]interface{}{"deep": []interface{}{map[string]interface{}{"text": "héllo"}}},
+		},
+		{
+			name: "non-strings untouched",
+			in:   []interface{}{1.5, nil, false},
+			want: []interface{}{1.5, nil, false},
+		},
+	}
+	defer func(n int) { ExampleMaxLength = n }(ExampleMaxLength)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ExampleMaxLength = tt.maxLen
+			if got := sanitizeExample(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sanitizeExample() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBannedWordRule(t *testing.T) {
+	tests := []struct {
+		word, text, want string
+	}{
+		{"spam", "no SPAM here", "SPAM"},
+		{"spam", "spammy offers", ""},
+		{"spam", "antispam filter", ""},
+		{"c++", "Learn C++ today", "C++"},
+		{"c++", "c++", "c++"},
+		{"@handle", "ping @handle, thanks", "@handle"},
+		{"@handle", "mail me@handle.example", ""},
+		{"$$$", "make $$$ fast", "$$$"},
+		{"#1", "(#1) pick", "#1"},
+		{"#1", "#10 pick", ""},
+	}
+	for _, tt := range tests {
+		if got := BannedWordRule(tt.word).find(tt.text); got != tt.want {
+			t.Errorf("BannedWordRule(%q) in %q = %q, want %q", tt.word, tt.text, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	AnthropicEndpoint  = "https://api.anthropic.com/v1/messages"
+	AnthropicModel     = "claude-3-5-haiku-latest"
+	anthropicVersion   = "2023-06-01"
+	anthropicMaxTokens = 4096
+)
+
+// anthropicProvider talks to the Anthropic Messages API.
+type anthropicProvider struct {
+	endpoint string
+	model    string
+	apiKey   string
+	cfg      Config
+}
+
+func (p *anthropicProvider) Name() string { return "Anthropic" }
+
+func (p *anthropicProvider) Model() string { return p.model }
+
+func (p *anthropicProvider) Generate(ctx context.Context, userPrompt string) (string, error) {
+	requestBody := map[string]interface{}{
+		"model":      p.model,
+		"max_tokens": anthropicMaxTokens,
+		"messages": []map[string]string{
+			{"role": "user", "content": userPrompt},
+		},
+	}
+	if p.cfg.Temperature != nil {
+		// Anthropic accepts 0–1, so the OpenAI-style 0–2 range is halved.
+		requestBody["temperature"] = *p.cfg.Temperature / 2
+	}
+	jsonBody, _ := json.Marshal(requestBody)
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage struct {
+			InputTokens  int `json:"input_tokens"`
+			OutputTokens int `json:"output_tokens"`
+		} `json:"usage"`
+	}
+	err := p.cfg.retryLLM(ctx, p.Name(), p.endpoint, func() error {
+		body, err := p.cfg.postLLM(ctx, p.Name(), p.endpoint, jsonBody, func(req *http.Request) error {
+			req.Header.Set("x-api-key", p.apiKey)
+			req.Header.Set("anthropic-version", anthropicVersion)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("could not parse Anthropic API response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	p.cfg.usage().Add(ctx, p.Name(), tokenUsage{Prompt: result.Usage.InputTokens, Completion: result.Usage.OutputTokens})
+
+	var text strings.Builder
+	for _, block := range result.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no text returned from Anthropic")
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// AuthFunc attaches credentials to an outgoing provider request. It receives
+// the serialized request body so signing schemes can cover it.
+type AuthFunc func(req *http.Request, body []byte) error
+
+func noAuth(req *http.Request, body []byte) error { return nil }
+
+// BearerAuth sends token as a bearer token.
+func BearerAuth(token string) AuthFunc {
+	return func(req *http.Request, body []byte) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// HMACAuth signs "<unix timestamp>\n<body>" with HMAC-SHA256 for gateways
+// that reject bearer tokens.
+func HMACAuth(keyID, secret string) AuthFunc {
+	return func(req *http.Request, body []byte) error {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "\n"))
+		mac.Write(body)
+
+		req.Header.Set("X-Timestamp", ts)
+		req.Header.Set("X-Signature", hex.EncodeToString(mac.Sum(nil)))
+		if keyID != "" {
+			req.Header.Set("X-Key-Id", keyID)
+		}
+		return nil
+	}
+}
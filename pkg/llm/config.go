@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"time"
+
+	"promptcraft-groq/internal/retry"
+)
+
+// Config selects the model provider and how requests to it are made. Start
+// from DefaultConfig; providers and image generators keep a copy of the
+// Config they were built from.
+type Config struct {
+	// Provider is groq, openai, anthropic, gemini or ollama.
+	Provider string
+	// Endpoint and Model replace the provider's defaults when set.
+	Endpoint string
+	Model    string
+
+	GroqAPIKey string
+	// GroqAuth authenticates Groq requests instead of sending GroqAPIKey
+	// as a bearer token, e.g. HMACAuth for gateways that sign requests.
+	GroqAuth        AuthFunc
+	OpenAIAPIKey    string
+	AnthropicAPIKey string
+	GeminiAPIKey    string
+
+	// Temperature is sent with every model request when set.
+	Temperature *float64
+	// Stream uses the streaming chat-completions API for plain
+	// generations on OpenAI-compatible providers.
+	Stream bool
+
+	// Timeout bounds a single request. Network errors and 429/5xx
+	// responses are retried MaxRetries times, backing off exponentially
+	// from RetryBackoff.
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// RateLimit caps model and image requests per minute, retries
+	// included, across every provider sharing it; nil means no limit.
+	RateLimit *retry.Limiter
+
+	// Usage records the tokens used and holds the daily budget; nil
+	// records to the process-wide Usage ledger.
+	Usage *UsageLedger
+
+	Image ImageConfig
+}
+
+// ImageConfig picks the image API, which is configured separately from the
+// text model.
+type ImageConfig struct {
+	// Provider is openai or stability; empty disables image generation.
+	Provider string
+	APIKey   string
+	Model    string
+	// Size is the OpenAI image size, or a Stability aspect ratio such as
+	// 16:9.
+	Size string
+	// Endpoint replaces the provider's images endpoint when set.
+	Endpoint string
+}
+
+// DefaultConfig returns the settings used when nothing is configured: Groq
+// with its default model, three retries and a 20s timeout.
+func DefaultConfig() Config {
+	return Config{
+		Provider:     "groq",
+		Timeout:      20 * time.Second,
+		MaxRetries:   3,
+		RetryBackoff: time.Second,
+		Image:        ImageConfig{Size: "1792x1024"},
+	}
+}
+
+// NewRateLimit allows perMinute requests a minute, or returns nil for no
+// limit when perMinute is not positive.
+func NewRateLimit(perMinute int) *retry.Limiter {
+	return retry.NewLimiter(perMinute)
+}
+
+// MissingAPIKey names the API key variable the provider needs but does not
+// have, or returns "".
+func (c Config) MissingAPIKey() string {
+	switch c.Provider {
+	case "ollama":
+	case "openai":
+		if c.OpenAIAPIKey == "" {
+			return "OPENAI_API_KEY"
+		}
+	case "anthropic":
+		if c.AnthropicAPIKey == "" {
+			return "ANTHROPIC_API_KEY"
+		}
+	case "gemini":
+		if c.GeminiAPIKey == "" {
+			return "GEMINI_API_KEY"
+		}
+	default:
+		if c.GroqAPIKey == "" && c.GroqAuth == nil {
+			return "GROQ_API_KEY"
+		}
+	}
+	return ""
+}
+
+func (c Config) usage() *UsageLedger {
+	if c.Usage != nil {
+		return c.Usage
+	}
+	return Usage
+}
@@ -0,0 +1,96 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// GeminiEndpoint is the API base; the model is part of the request path.
+const (
+	GeminiEndpoint = "https://generativelanguage.googleapis.com/v1beta"
+	GeminiModel    = "gemini-1.5-flash"
+)
+
+// geminiProvider talks to the Google Gemini generateContent API.
+type geminiProvider struct {
+	endpoint string
+	model    string
+	apiKey   string
+	cfg      Config
+}
+
+func (p *geminiProvider) Name() string { return "Gemini" }
+
+func (p *geminiProvider) Model() string { return p.model }
+
+func (p *geminiProvider) Generate(ctx context.Context, userPrompt string) (string, error) {
+	return p.generate(ctx, userPrompt, false)
+}
+
+// GenerateJSON asks for an application/json response.
+func (p *geminiProvider) GenerateJSON(ctx context.Context, userPrompt string) (string, error) {
+	return p.generate(ctx, userPrompt, true)
+}
+
+func (p *geminiProvider) generate(ctx context.Context, userPrompt string, jsonMode bool) (string, error) {
+	requestBody := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{"role": "user", "parts": []map[string]string{{"text": userPrompt}}},
+		},
+	}
+	config := map[string]interface{}{}
+	if p.cfg.Temperature != nil {
+		config["temperature"] = *p.cfg.Temperature
+	}
+	if jsonMode {
+		config["responseMimeType"] = "application/json"
+	}
+	if len(config) > 0 {
+		requestBody["generationConfig"] = config
+	}
+	jsonBody, _ := json.Marshal(requestBody)
+	url := strings.TrimRight(p.endpoint, "/") + "/models/" + p.model + ":generateContent"
+
+	var result struct {
+		Candidates []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	err := p.cfg.retryLLM(ctx, p.Name(), url, func() error {
+		body, err := p.cfg.postLLM(ctx, p.Name(), url, jsonBody, func(req *http.Request) error {
+			req.Header.Set("x-goog-api-key", p.apiKey)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("could not parse Gemini API response: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	p.cfg.usage().Add(ctx, p.Name(), tokenUsage{Prompt: result.UsageMetadata.PromptTokenCount, Completion: result.UsageMetadata.CandidatesTokenCount})
+
+	if len(result.Candidates) == 0 {
+		return "", fmt.Errorf("no candidates returned from Gemini")
+	}
+	var text strings.Builder
+	for _, part := range result.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/internal/runctx"
+)
+
+const (
+	OpenAIImagesEndpoint    = "https://api.openai.com/v1/images/generations"
+	OpenAIImageModel        = "dall-e-3"
+	StabilityImagesEndpoint = "https://api.stability.ai/v2beta/stable-image/generate/core"
+)
+
+// Image is a generated image and its MIME type.
+type Image struct {
+	Data        []byte
+	ContentType string
+}
+
+// ImageGenerator makes images with the API its Config.Image names, under
+// the same timeout, retries and rate limit as the model requests.
+type ImageGenerator struct {
+	cfg Config
+}
+
+// NewImageGenerator returns a generator for cfg.Image, or nil when no image
+// provider is set.
+func NewImageGenerator(cfg Config) (*ImageGenerator, error) {
+	switch cfg.Image.Provider {
+	case "":
+		return nil, nil
+	case "openai", "stability":
+		return &ImageGenerator{cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown image provider %q (want openai or stability)", cfg.Image.Provider)
+}
+
+// Generate asks the image provider for one image of description.
+func (g *ImageGenerator) Generate(ctx context.Context, description string) (Image, error) {
+	var img Image
+	err := retry.Do(ctx, "Image request failed", g.cfg.MaxRetries, g.cfg.RetryBackoff, isRetryable, func() error {
+		var err error
+		if g.cfg.Image.Provider == "stability" {
+			img, err = g.stabilityImage(ctx, description)
+		} else {
+			img, err = g.openAIImage(ctx, description)
+		}
+		return err
+	})
+	return img, err
+}
+
+func (g *ImageGenerator) openAIImage(ctx context.Context, description string) (Image, error) {
+	model := g.cfg.Image.Model
+	if model == "" {
+		model = OpenAIImageModel
+	}
+	jsonBody, _ := json.Marshal(map[string]interface{}{
+		"model":           model,
+		"prompt":          description,
+		"n":               1,
+		"size":            g.cfg.Image.Size,
+		"response_format": "b64_json",
+	})
+	body, _, err := g.postImage(ctx, "OpenAI Images", g.endpoint(OpenAIImagesEndpoint), "application/json", bytes.NewReader(jsonBody), "")
+	if err != nil {
+		return Image{}, err
+	}
+
+	var result struct {
+		Data []struct {
+			B64JSON string `json:"b64_json"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return Image{}, fmt.Errorf("could not parse OpenAI Images response: %w", err)
+	}
+	if len(result.Data) == 0 {
+		return Image{}, fmt.Errorf("no image returned from OpenAI Images")
+	}
+	data, err := base64.StdEncoding.DecodeString(result.Data[0].B64JSON)
+	if err != nil {
+		return Image{}, fmt.Errorf("could not decode OpenAI image: %w", err)
+	}
+	return Image{Data: data, ContentType: "image/png"}, nil
+}
+
+// stabilityImage uses the Stable Image API, which takes a multipart form
+// and answers with the image bytes. Image.Size is sent as its aspect
+// ratio, e.g. 16:9.
+func (g *ImageGenerator) stabilityImage(ctx context.Context, description string) (Image, error) {
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	w.WriteField("prompt", description)
+	w.WriteField("output_format", "png")
+	if strings.Contains(g.cfg.Image.Size, ":") {
+		w.WriteField("aspect_ratio", g.cfg.Image.Size)
+	}
+	if g.cfg.Image.Model != "" {
+		w.WriteField("model", g.cfg.Image.Model)
+	}
+	w.Close()
+
+	body, contentType, err := g.postImage(ctx, "Stability", g.endpoint(StabilityImagesEndpoint), w.FormDataContentType(), &form, "image/*")
+	if err != nil {
+		return Image{}, err
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return Image{}, fmt.Errorf("Stability returned %s instead of an image", contentType)
+	}
+	return Image{Data: body, ContentType: contentType}, nil
+}
+
+func (g *ImageGenerator) endpoint(def string) string {
+	return override(g.cfg.Image.Endpoint, def)
+}
+
+// postImage makes one image API request under the timeout and returns the
+// body and its content type.
+func (g *ImageGenerator) postImage(ctx context.Context, provider, url, contentType string, body io.Reader, accept string) ([]byte, string, error) {
+	// Image requests share the rate limit with the model calls.
+	if err := g.cfg.RateLimit.Wait(ctx); err != nil {
+		return nil, "", err
+	}
+	parent := ctx
+	ctx, cancel := runctx.WithStageTimeout(ctx, g.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, body)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+g.cfg.Image.APIKey)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	runctx.SetRunIDHeader(ctx, req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, "", runctx.StageError(parent, ctx, "image", g.cfg.Timeout, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &statusError{provider: provider, status: resp.StatusCode, body: string(respBody)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			statusErr.retryAfter, _ = retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return nil, "", statusErr
+	}
+	return respBody, resp.Header.Get("Content-Type"), nil
+}
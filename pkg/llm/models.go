@@ -0,0 +1,37 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// modelsEndpoint derives the OpenAI-compatible /models URL from the
+// chat-completions endpoint.
+func modelsEndpoint(endpoint string) string {
+	return strings.TrimSuffix(strings.TrimRight(endpoint, "/"), "/chat/completions") + "/models"
+}
+
+// ListModels returns the models p offers.
+func ListModels(ctx context.Context, p Provider) ([]string, error) {
+	lister, ok := p.(modelLister)
+	if !ok {
+		return nil, fmt.Errorf("%s does not support listing models", p.Name())
+	}
+	return lister.ListModels(ctx)
+}
+
+// Warmup lists the models once so the first real generation reuses a warm
+// connection, and so a bad key shows up in the logs at startup.
+func Warmup(ctx context.Context, p Provider) {
+	start := time.Now()
+	models, err := ListModels(ctx, p)
+	if err != nil {
+		runctx.Logln(ctx, "⚠️ Warm-up request failed:", err)
+		return
+	}
+	runctx.Logf(ctx, "🔥 Warm-up done in %s (%d models available)", time.Since(start).Round(time.Millisecond), len(models))
+}
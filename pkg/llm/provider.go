@@ -0,0 +1,402 @@
+// Package llm talks to the model providers: Groq, OpenAI, Ollama,
+// Anthropic and Gemini. New builds a Provider from a Config.
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"promptcraft-groq/internal/metrics"
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/internal/runctx"
+)
+
+// ChatResponse is the body of an OpenAI-compatible chat completion.
+type ChatResponse struct {
+	Choices []struct {
+		Message struct {
+			Content   string `json:"content"`
+			ToolCalls []struct {
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"message"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+}
+
+const (
+	GroqEndpoint = "https://api.groq.com/openai/v1/chat/completions"
+	GroqModel    = "llama3-70b-8192"
+)
+
+var (
+	Breakers = &retry.Breakers{Name: "LLM"}
+	Latency  = metrics.NewHistogram()
+)
+
+func (p *chatProvider) ListModels(ctx context.Context) ([]string, error) {
+	ctx, cancel := runctx.WithStageTimeout(ctx, p.cfg.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", modelsEndpoint(p.endpoint), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.auth(req, nil); err != nil {
+		return nil, fmt.Errorf("could not authenticate %s request: %w", p.name, err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("authentication failed (%s): check the %s API key: %s", resp.Status, p.name, body)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("models request failed (%s): %s", resp.Status, body)
+	}
+
+	var result struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("could not parse models response: %w", err)
+	}
+
+	ids := make([]string, 0, len(result.Data))
+	for _, m := range result.Data {
+		ids = append(ids, m.ID)
+	}
+	return ids, nil
+}
+
+// Provider is an LLM backend that turns a prompt into text.
+type Provider interface {
+	Name() string
+	Model() string
+	Generate(ctx context.Context, prompt string) (string, error)
+}
+
+// ChatCompleter is implemented by providers that accept raw
+// chat-completions requests, which tool calling needs.
+type ChatCompleter interface {
+	Complete(ctx context.Context, requestBody map[string]interface{}) (*ChatResponse, error)
+}
+
+// JSONGenerator is implemented by providers with a JSON mode, which makes
+// the model answer with a single JSON object.
+type JSONGenerator interface {
+	GenerateJSON(ctx context.Context, prompt string) (string, error)
+}
+
+// modelLister is implemented by providers that can list their models.
+type modelLister interface {
+	ListModels(ctx context.Context) ([]string, error)
+}
+
+const (
+	OpenAIEndpoint = "https://api.openai.com/v1/chat/completions"
+	OpenAIModel    = "gpt-4o-mini"
+	OllamaEndpoint = "http://localhost:11434/v1/chat/completions"
+	OllamaModel    = "llama3"
+)
+
+// chatProvider talks to an OpenAI-compatible chat-completions API, which
+// covers Groq, OpenAI and Ollama.
+type chatProvider struct {
+	name     string
+	endpoint string
+	model    string
+	auth     AuthFunc
+	cfg      Config
+}
+
+func (p *chatProvider) Name() string { return p.name }
+
+func (p *chatProvider) Model() string { return p.model }
+
+func (p *chatProvider) Generate(ctx context.Context, userPrompt string) (string, error) {
+	requestBody := map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": userPrompt},
+		},
+	}
+	if p.cfg.Stream {
+		return p.stream(ctx, requestBody)
+	}
+
+	result, err := p.Complete(ctx, requestBody)
+	if err != nil {
+		return "", err
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// GenerateJSON sets response_format to json_object. It does not stream,
+// since the object is only usable once complete.
+func (p *chatProvider) GenerateJSON(ctx context.Context, userPrompt string) (string, error) {
+	result, err := p.Complete(ctx, map[string]interface{}{
+		"model": p.model,
+		"messages": []map[string]string{
+			{"role": "user", "content": userPrompt},
+		},
+		"response_format": map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Choices[0].Message.Content, nil
+}
+
+// Complete sends a chat completion request, retrying network errors and
+// 429/5xx responses up to Config.MaxRetries times with exponential backoff.
+func (p *chatProvider) Complete(ctx context.Context, requestBody map[string]interface{}) (*ChatResponse, error) {
+	if _, ok := requestBody["temperature"]; !ok && p.cfg.Temperature != nil {
+		requestBody["temperature"] = *p.cfg.Temperature
+	}
+	jsonBody, _ := json.Marshal(requestBody)
+
+	var result ChatResponse
+	err := p.cfg.retryLLM(ctx, p.name, p.endpoint, func() error {
+		body, err := p.cfg.postLLM(ctx, p.name, p.endpoint, jsonBody, func(req *http.Request) error {
+			return p.auth(req, jsonBody)
+		})
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(body, &result); err != nil {
+			return fmt.Errorf("could not parse %s API response: %w", p.name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	p.cfg.usage().Add(ctx, p.name, result.Usage.tokens())
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("no choices returned from %s", p.name)
+	}
+	return &result, nil
+}
+
+// chatChunk is one event of a streamed chat completion. Groq reports the
+// usage under x_groq on the last chunk, others under usage.
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *chatUsage `json:"usage"`
+	XGroq struct {
+		Usage *chatUsage `json:"usage"`
+	} `json:"x_groq"`
+}
+
+// stream runs a chat completion with "stream": true, assembling the
+// content from the deltas.
+func (p *chatProvider) stream(ctx context.Context, requestBody map[string]interface{}) (string, error) {
+	requestBody["stream"] = true
+	requestBody["stream_options"] = map[string]bool{"include_usage": true}
+	if p.cfg.Temperature != nil {
+		requestBody["temperature"] = *p.cfg.Temperature
+	}
+	jsonBody, _ := json.Marshal(requestBody)
+
+	var text strings.Builder
+	var used *chatUsage
+	err := p.cfg.retryLLM(ctx, p.name, p.endpoint, func() error {
+		text.Reset()
+		return p.cfg.streamLLM(ctx, p.name, p.endpoint, jsonBody, func(req *http.Request) error {
+			return p.auth(req, jsonBody)
+		}, func(data []byte) error {
+			var chunk chatChunk
+			if err := json.Unmarshal(data, &chunk); err != nil {
+				return fmt.Errorf("could not parse %s stream event: %w", p.name, err)
+			}
+			for _, c := range chunk.Choices {
+				text.WriteString(c.Delta.Content)
+			}
+			if chunk.Usage != nil {
+				used = chunk.Usage
+			} else if chunk.XGroq.Usage != nil {
+				used = chunk.XGroq.Usage
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	p.cfg.usage().Add(ctx, p.name, used.tokens())
+	if text.Len() == 0 {
+		return "", fmt.Errorf("no content streamed from %s", p.name)
+	}
+	return text.String(), nil
+}
+
+// retryLLM runs fn through the endpoint's breaker, retrying network errors
+// and 429/5xx responses up to MaxRetries times with exponential backoff.
+// Nothing is sent once the daily token budget is used up.
+func (c Config) retryLLM(ctx context.Context, provider, endpoint string, fn func() error) error {
+	if err := c.usage().CheckBudget(); err != nil {
+		return err
+	}
+	breaker := Breakers.For(endpoint)
+	attempts := 0
+	err := retry.Do(ctx, provider+" request failed", c.MaxRetries, c.RetryBackoff, isRetryable, func() error {
+		attempts++
+		return breaker.Call(isRetryable, fn)
+	})
+	if err != nil && attempts > 1 {
+		return fmt.Errorf("after %d attempts: %w", attempts, err)
+	}
+	return err
+}
+
+// postLLM makes a single JSON POST to a provider under Timeout and
+// returns the body of a 200 response. auth attaches the provider's
+// credentials; any other status becomes a statusError.
+func (c Config) postLLM(ctx context.Context, provider, url string, jsonBody []byte, auth func(*http.Request) error) ([]byte, error) {
+	var body []byte
+	err := c.doLLM(ctx, provider, url, jsonBody, auth, func(r io.Reader) (err error) {
+		body, err = io.ReadAll(r)
+		return err
+	})
+	return body, err
+}
+
+// streamLLM is postLLM for server-sent events: onEvent gets the data of
+// each event as it arrives, until the "[DONE]" marker.
+func (c Config) streamLLM(ctx context.Context, provider, url string, jsonBody []byte, auth func(*http.Request) error, onEvent func([]byte) error) error {
+	return c.doLLM(ctx, provider, url, jsonBody, auth, func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data:")
+			if !ok {
+				continue
+			}
+			data = strings.TrimSpace(data)
+			if data == "[DONE]" {
+				return nil
+			}
+			if err := onEvent([]byte(data)); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+func (c Config) doLLM(ctx context.Context, provider, url string, jsonBody []byte, auth func(*http.Request) error, read func(io.Reader) error) error {
+	// Waiting for the rate limit does not count against the timeout.
+	if err := c.RateLimit.Wait(ctx); err != nil {
+		return err
+	}
+	parent := ctx
+	ctx, cancel := runctx.WithStageTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	runctx.SetRunIDHeader(ctx, req)
+	if err := auth(req); err != nil {
+		return fmt.Errorf("could not authenticate %s request: %w", provider, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	defer func() { Latency.Observe(strings.ToLower(provider), time.Since(start)) }()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return runctx.StageError(parent, ctx, "LLM", c.Timeout, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		statusErr := &statusError{provider: provider, status: resp.StatusCode, body: string(body)}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			statusErr.retryAfter, _ = retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		}
+		return statusErr
+	}
+	if err := read(resp.Body); err != nil {
+		return runctx.StageError(parent, ctx, "LLM", c.Timeout, err)
+	}
+	return nil
+}
+
+// New builds the provider cfg names. Endpoint and Model, when set,
+// replace the provider's defaults.
+func New(cfg Config) (Provider, error) {
+	endpoint := func(def string) string { return override(cfg.Endpoint, def) }
+	model := func(def string) string { return override(cfg.Model, def) }
+
+	switch cfg.Provider {
+	case "groq":
+		auth := cfg.GroqAuth
+		if auth == nil {
+			auth = BearerAuth(cfg.GroqAPIKey)
+		}
+		return &chatProvider{name: "Groq", endpoint: endpoint(GroqEndpoint), model: model(GroqModel), auth: auth, cfg: cfg}, nil
+	case "openai":
+		return &chatProvider{name: "OpenAI", endpoint: endpoint(OpenAIEndpoint), model: model(OpenAIModel), auth: BearerAuth(cfg.OpenAIAPIKey), cfg: cfg}, nil
+	case "ollama":
+		// Ollama serves the OpenAI-compatible API and needs no key.
+		return &chatProvider{name: "Ollama", endpoint: endpoint(OllamaEndpoint), model: model(OllamaModel), auth: noAuth, cfg: cfg}, nil
+	case "anthropic":
+		return &anthropicProvider{endpoint: endpoint(AnthropicEndpoint), model: model(AnthropicModel), apiKey: cfg.AnthropicAPIKey, cfg: cfg}, nil
+	case "gemini":
+		return &geminiProvider{endpoint: endpoint(GeminiEndpoint), model: model(GeminiModel), apiKey: cfg.GeminiAPIKey, cfg: cfg}, nil
+	}
+	return nil, fmt.Errorf("unknown provider %q (want groq, openai, anthropic, gemini or ollama)", cfg.Provider)
+}
+
+func override(s, def string) string {
+	if s != "" {
+		return s
+	}
+	return def
+}
+
+// WithModel returns a copy of the provider that uses a different model.
+func WithModel(p Provider, model string) Provider {
+	switch p := p.(type) {
+	case *chatProvider:
+		c := *p
+		c.model = model
+		return &c
+	case *anthropicProvider:
+		c := *p
+		c.model = model
+		return &c
+	case *geminiProvider:
+		c := *p
+		c.model = model
+		return &c
+	}
+	return p
+}
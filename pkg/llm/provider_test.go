@@ -0,0 +1,136 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// chatServer answers chat completions with content, after failing the
+// first fail requests with a 503. It records the last request body.
+func chatServer(t *testing.T, fail int, content string, got *map[string]interface{}) (*httptest.Server, *int) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		json.NewDecoder(r.Body).Decode(got)
+		if calls <= fail {
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []interface{}{map[string]interface{}{"message": map[string]string{"content": content}}},
+			"usage":   map[string]int{"prompt_tokens": 7, "completion_tokens": 5},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func testConfig(endpoint string) Config {
+	cfg := DefaultConfig()
+	cfg.Provider = "openai"
+	cfg.Endpoint = endpoint
+	cfg.OpenAIAPIKey = "test-key"
+	cfg.RetryBackoff = time.Millisecond
+	cfg.Usage = NewUsageLedger(0)
+	return cfg
+}
+
+func TestNewGenerate(t *testing.T) {
+	var body map[string]interface{}
+	srv, _ := chatServer(t, 0, "hello", &body)
+	cfg := testConfig(srv.URL)
+	temp := 0.3
+	cfg.Temperature = &temp
+	cfg.Model = "small"
+
+	p, err := New(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := p.Generate(context.Background(), "hi")
+	if err != nil || got != "hello" {
+		t.Fatalf("Generate() = %q, %v", got, err)
+	}
+	if p.Name() != "OpenAI" || p.Model() != "small" || body["model"] != "small" || body["temperature"] != 0.3 {
+		t.Errorf("provider %s/%s sent %v", p.Name(), p.Model(), body)
+	}
+	if used := cfg.Usage.Today(); used != 12 {
+		t.Errorf("Usage.Today() = %d, want 12", used)
+	}
+}
+
+func TestGenerateRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		wantCalls  int
+		wantErr    bool
+	}{
+		{"recovers", 2, 3, false},
+		{"gives up", 1, 2, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := chatServer(t, 2, "ok", new(map[string]interface{}))
+			cfg := testConfig(srv.URL)
+			cfg.MaxRetries = tt.maxRetries
+			p, _ := New(cfg)
+			_, err := p.Generate(context.Background(), "hi")
+			if (err != nil) != tt.wantErr || *calls != tt.wantCalls {
+				t.Errorf("Generate() err = %v after %d calls, want error %t after %d", err, *calls, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestGenerateBudget(t *testing.T) {
+	srv, calls := chatServer(t, 0, "ok", new(map[string]interface{}))
+	cfg := testConfig(srv.URL)
+	cfg.Usage = NewUsageLedger(10)
+	p, _ := New(cfg)
+
+	if _, err := p.Generate(context.Background(), "hi"); err != nil {
+		t.Fatal(err)
+	}
+	_, err := p.Generate(context.Background(), "hi")
+	var budgetErr *BudgetError
+	if !errors.As(err, &budgetErr) || *calls != 1 {
+		t.Errorf("Generate() over budget = %v after %d calls, want a BudgetError after 1", err, *calls)
+	}
+}
+
+func TestNewUnknownProvider(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Provider = "acme"
+	if _, err := New(cfg); err == nil {
+		t.Error("New() accepted an unknown provider")
+	}
+}
+
+func TestMissingAPIKey(t *testing.T) {
+	tests := []struct {
+		cfg  Config
+		want string
+	}{
+		{Config{Provider: "groq"}, "GROQ_API_KEY"},
+		{Config{Provider: "groq", GroqAPIKey: "k"}, ""},
+		{Config{Provider: "groq", GroqAuth: HMACAuth("id", "secret")}, ""},
+		{Config{Provider: "openai"}, "OPENAI_API_KEY"},
+		{Config{Provider: "anthropic", AnthropicAPIKey: "k"}, ""},
+		{Config{Provider: "gemini"}, "GEMINI_API_KEY"},
+		{Config{Provider: "ollama"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.cfg.MissingAPIKey(); got != tt.want {
+			t.Errorf("%s MissingAPIKey() = %q, want %q", tt.cfg.Provider, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// statusError is a non-200 response from the provider API.
+type statusError struct {
+	provider   string
+	status     int
+	body       string
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s API returned %d %s: %s", e.provider, e.status, http.StatusText(e.status), e.body)
+}
+
+func (e *statusError) RetryAfter() time.Duration { return e.retryAfter }
+
+// isRetryable retries network errors and 429/500/502/503, but not
+// client errors such as 400 or 401, nor the run being cancelled.
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+			return true
+		}
+		return false
+	}
+	var netErr *url.Error
+	return errors.As(err, &netErr) && !errors.Is(err, context.Canceled)
+}
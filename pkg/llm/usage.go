@@ -0,0 +1,187 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+)
+
+// usageDays is how many days of token counts the usage file keeps.
+var usageDays = 31
+
+// tokenUsage counts the tokens of one or more model calls.
+type tokenUsage struct {
+	Prompt     int `json:"prompt"`
+	Completion int `json:"completion"`
+}
+
+func (u tokenUsage) Total() int { return u.Prompt + u.Completion }
+
+// chatUsage is the usage block of OpenAI-compatible responses.
+type chatUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *chatUsage) tokens() tokenUsage {
+	if u == nil {
+		return tokenUsage{}
+	}
+	return tokenUsage{Prompt: u.PromptTokens, Completion: u.CompletionTokens}
+}
+
+// UsageLedger accumulates token counts per day and job, persisted so the
+// daily budget survives restarts.
+type UsageLedger struct {
+	mu sync.Mutex
+	// days maps a date to per-job counts; the default job is "".
+	days map[string]map[string]*tokenUsage
+	// lifetime counts tokens since the process started, for /metrics.
+	lifetime map[string]*tokenUsage
+	// budget suspends generation once this many tokens have been used
+	// today; 0 disables it.
+	budget int
+	file   *state.File
+}
+
+// Usage is the ledger providers record to when their Config has none.
+var Usage = NewUsageLedger(0)
+
+// NewUsageLedger returns an empty ledger with a daily budget of budget
+// tokens, or none when budget is 0. It is not persisted.
+func NewUsageLedger(budget int) *UsageLedger {
+	return &UsageLedger{days: map[string]map[string]*tokenUsage{}, lifetime: map[string]*tokenUsage{}, budget: budget}
+}
+
+// LoadUsageLedger reads the token usage file at path.
+func LoadUsageLedger(path string, budget int) *UsageLedger {
+	l := NewUsageLedger(budget)
+	l.file = state.Register(path, l.marshal)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("⚠️ Could not read token usage file:", err)
+		}
+		return l
+	}
+	if err := json.Unmarshal(data, &l.days); err != nil {
+		log.Println("⚠️ Could not parse token usage file, starting empty:", err)
+		l.days = map[string]map[string]*tokenUsage{}
+	}
+	return l
+}
+
+func (l *UsageLedger) marshal() ([]byte, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return json.MarshalIndent(l.days, "", "  ")
+}
+
+func today() string { return time.Now().Format("2006-01-02") }
+
+// Add records a model call's usage against the run's job.
+func (l *UsageLedger) Add(ctx context.Context, provider string, u tokenUsage) {
+	if u.Total() == 0 {
+		return
+	}
+	job := runctx.JobName(ctx)
+
+	l.mu.Lock()
+	day := today()
+	if l.days[day] == nil {
+		l.days[day] = map[string]*tokenUsage{}
+		l.pruneLocked()
+	}
+	for _, m := range []map[string]*tokenUsage{l.days[day], l.lifetime} {
+		if m[job] == nil {
+			m[job] = &tokenUsage{}
+		}
+		m[job].Prompt += u.Prompt
+		m[job].Completion += u.Completion
+	}
+	used := l.todayLocked()
+	l.mu.Unlock()
+	if l.file != nil {
+		l.file.MarkDirty()
+	}
+
+	runctx.Logf(ctx, "🧮 %s used %d prompt + %d completion tokens (%d today)", provider, u.Prompt, u.Completion, used)
+}
+
+func (l *UsageLedger) pruneLocked() {
+	days := make([]string, 0, len(l.days))
+	for d := range l.days {
+		days = append(days, d)
+	}
+	sort.Strings(days)
+	for len(days) > usageDays {
+		delete(l.days, days[0])
+		days = days[1:]
+	}
+}
+
+func (l *UsageLedger) todayLocked() int {
+	total := 0
+	for _, u := range l.days[today()] {
+		total += u.Total()
+	}
+	return total
+}
+
+// Today returns the tokens used today across all jobs.
+func (l *UsageLedger) Today() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.todayLocked()
+}
+
+// BudgetError reports that the daily token budget has been used up.
+type BudgetError struct {
+	used, budget int
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("daily token budget of %d used up (%d used today)", e.budget, e.used)
+}
+
+// CheckBudget fails once today's usage has reached the daily budget.
+func (l *UsageLedger) CheckBudget() error {
+	if l.budget <= 0 {
+		return nil
+	}
+	if used := l.Today(); used >= l.budget {
+		return &BudgetError{used: used, budget: l.budget}
+	}
+	return nil
+}
+
+func (l *UsageLedger) WriteMetrics(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP autopost_llm_tokens_total Model tokens used since the process started.\n# TYPE autopost_llm_tokens_total counter\n")
+	jobs := make([]string, 0, len(l.lifetime))
+	for j := range l.lifetime {
+		jobs = append(jobs, j)
+	}
+	sort.Strings(jobs)
+	for _, j := range jobs {
+		label := j
+		if label == "" {
+			label = "default"
+		}
+		fmt.Fprintf(w, "autopost_llm_tokens_total{job=%q,type=\"prompt\"} %d\n", label, l.lifetime[j].Prompt)
+		fmt.Fprintf(w, "autopost_llm_tokens_total{job=%q,type=\"completion\"} %d\n", label, l.lifetime[j].Completion)
+	}
+	fmt.Fprintf(w, "# HELP autopost_llm_tokens_today Model tokens used today across all jobs.\n# TYPE autopost_llm_tokens_today gauge\nautopost_llm_tokens_today %d\n", l.todayLocked())
+	fmt.Fprintf(w, "# HELP autopost_llm_token_budget Daily token budget (0 means none).\n# TYPE autopost_llm_token_budget gauge\nautopost_llm_token_budget %d\n", l.budget)
+}
@@ -0,0 +1,188 @@
+package output
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Besides BACKEND_API_TOKEN the backend can take a static API key header,
+// extra headers, or a bearer token from an OAuth2 client-credentials grant.
+var (
+	BackendAPIKey       string
+	BackendAPIKeyHeader = "X-API-Key"
+	BackendHeaders      map[string]string
+
+	OAuthTokenURL     string
+	OAuthClientID     string
+	OAuthClientSecret string
+	OAuthScopes       []string
+	// OAuthAudience is sent as "audience", which Auth0 and similar issuers
+	// require.
+	OAuthAudience string
+)
+
+// oauthMargin renews a token this long before it expires, so it never
+// lapses mid-request.
+const oauthMargin = 30 * time.Second
+
+var oauthToken struct {
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// backendHeader returns the credentials and extra headers sent with every
+// backend request, fetching an OAuth2 token when one is due.
+func backendHeader(ctx context.Context) (http.Header, error) {
+	header := http.Header{}
+	for k, v := range BackendHeaders {
+		header.Set(k, v)
+	}
+	if BackendAPIKey != "" {
+		header.Set(BackendAPIKeyHeader, BackendAPIKey)
+	}
+	token := BackendAPIToken
+	if OAuthTokenURL != "" {
+		var err error
+		if token, err = oauthAccessToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	return header, nil
+}
+
+// backendHost reports whether rawURL is on the host of BACKEND_API_URL,
+// BACKEND_BULK_URL or BACKEND_UPLOAD_URL, the only ones trusted with the
+// backend credentials.
+func backendHost(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	for _, trusted := range []string{BackendAPI, BackendBulkURL, BackendUploadURL} {
+		if t, err := url.Parse(trusted); err == nil && trusted != "" && strings.EqualFold(t.Host, u.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// oauthAccessToken returns the cached client-credentials token, requesting
+// a new one when it is about to expire.
+func oauthAccessToken(ctx context.Context) (string, error) {
+	oauthToken.mu.Lock()
+	defer oauthToken.mu.Unlock()
+	if oauthToken.token != "" && time.Now().Before(oauthToken.expires) {
+		return oauthToken.token, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(OAuthScopes) > 0 {
+		form.Set("scope", strings.Join(OAuthScopes, " "))
+	}
+	if OAuthAudience != "" {
+		form.Set("audience", OAuthAudience)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", OAuthTokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(OAuthClientID), url.QueryEscape(OAuthClientSecret))
+
+	resp, err := backendClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("OAuth token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("OAuth token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.AccessToken == "" {
+		return "", fmt.Errorf("OAuth token endpoint sent no access_token: %s", body)
+	}
+	lifetime := time.Duration(result.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		// Without expires_in the token is refreshed hourly, or on a 401.
+		lifetime = time.Hour
+	}
+	oauthToken.token = result.AccessToken
+	oauthToken.expires = time.Now().Add(lifetime - min(oauthMargin, lifetime/2))
+	return oauthToken.token, nil
+}
+
+// invalidateOAuthToken drops the cached token after the backend rejected
+// it, e.g. because the issuer revoked it early.
+func invalidateOAuthToken() bool {
+	if OAuthTokenURL == "" {
+		return false
+	}
+	oauthToken.mu.Lock()
+	oauthToken.token = ""
+	oauthToken.mu.Unlock()
+	return true
+}
+
+// ConfigureBackendTLS presents the client certificate at certFile/keyFile
+// to the backend and trusts the PEM bundle at caFile on top of the system
+// roots. Either part may be empty.
+func ConfigureBackendTLS(certFile, keyFile, caFile string) error {
+	if (certFile == "") != (keyFile == "") {
+		return fmt.Errorf("a client certificate needs both a certificate and a key file")
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("could not load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("%s holds no PEM certificates", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	backendTransport().TLSClientConfig = cfg
+	return nil
+}
+
+// backendTransport returns backendClient's own transport, so TLS and
+// HTTP/1.1 settings combine without touching http.DefaultTransport.
+func backendTransport() *http.Transport {
+	t, ok := backendClient.Transport.(*http.Transport)
+	if !ok {
+		t = http.DefaultTransport.(*http.Transport).Clone()
+		backendClient.Transport = t
+	}
+	return t
+}
@@ -0,0 +1,30 @@
+package output
+
+import "testing"
+
+func TestBackendHost(t *testing.T) {
+	defer func(api, bulk, upload string) {
+		BackendAPI, BackendBulkURL, BackendUploadURL = api, bulk, upload
+	}(BackendAPI, BackendBulkURL, BackendUploadURL)
+	BackendAPI = "https://api.example.com/prompts"
+	BackendBulkURL = "https://bulk.example.com:8443/prompts/bulk"
+	BackendUploadURL = ""
+
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{"https://api.example.com/prompts", true},
+		{"https://API.example.com/other/path?x=1", true},
+		{"https://bulk.example.com:8443/anything", true},
+		{"https://bulk.example.com/anything", false},
+		{"https://hooks.example.org/prompts", false},
+		{"https://api.example.com.evil.test/prompts", false},
+		{"://bad", false},
+	}
+	for _, tt := range tests {
+		if got := backendHost(tt.url); got != tt.want {
+			t.Errorf("backendHost(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
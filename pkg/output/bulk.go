@@ -0,0 +1,115 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	BackendBulkURL string
+)
+
+// bulkResponse is the optional per-item result list a bulk endpoint may
+// return, in request order.
+type bulkResponse struct {
+	Results []struct {
+		Success bool   `json:"success"`
+		Error   string `json:"error"`
+	} `json:"results"`
+}
+
+// SendBulk posts the payloads and returns one error per item. A non-2xx
+// response, once retries are used up, fails every item; a 2xx with a
+// matching "results" list reports per-item failures, and anything else
+// counts as full success.
+func SendBulk(ctx context.Context, payloads []map[string]interface{}) []error {
+	errs := make([]error, len(payloads))
+	failAll := func(err error) []error {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+
+	body, err := json.Marshal(payloads)
+	if err != nil {
+		return failAll(err)
+	}
+
+	parent := ctx
+	ctx, cancel := runctx.WithStageTimeout(ctx, BackendTimeout)
+	defer cancel()
+
+	// Like backendSink.Send, transient failures are retried behind the
+	// endpoint's breaker and a 429 gets one more try after the cooldown.
+	var respBody []byte
+	breaker := Breakers.For(BackendBulkURL)
+	post := func() error {
+		return breaker.Call(isTransientBackendError, func() error {
+			var err error
+			respBody, err = postBulk(ctx, body)
+			return err
+		})
+	}
+	err = retry.Do(ctx, "Bulk request failed", BackendMaxRetries, BackendRetryBackoff, isTransientBackendError, func() error {
+		err := post()
+		if err == errBackendRateLimited {
+			runctx.Logln(ctx, "🔁 Retrying bulk request after 429 cooldown")
+			err = post()
+		}
+		return err
+	})
+	if err != nil {
+		return failAll(runctx.StageError(parent, ctx, "backend", BackendTimeout, err))
+	}
+
+	var result bulkResponse
+	if json.Unmarshal(respBody, &result) != nil || len(result.Results) != len(payloads) {
+		return errs
+	}
+	for i, r := range result.Results {
+		if !r.Success {
+			msg := r.Error
+			if msg == "" {
+				msg = "rejected by the bulk endpoint"
+			}
+			errs[i] = errors.New(msg)
+		}
+	}
+	return errs
+}
+
+// postBulk makes one bulk request and returns the response body of a 2xx.
+// A 429 pauses every backend send for its Retry-After.
+func postBulk(ctx context.Context, body []byte) ([]byte, error) {
+	if err := backendCooldown.Wait(ctx, "backend"); err != nil {
+		return nil, err
+	}
+	resp, err := postBackend(ctx, BackendBulkURL, BackendContentType, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			wait = BackendDefaultCooldown
+		}
+		runctx.Logf(ctx, "🚦 Bulk endpoint returned 429, pausing backend sends for %s", wait.Round(time.Second))
+		backendCooldown.Extend(wait)
+		return nil, errBackendRateLimited
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &backendStatusError{status: resp.StatusCode, body: string(respBody)}
+	}
+	return respBody, nil
+}
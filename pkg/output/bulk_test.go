@@ -0,0 +1,39 @@
+package output
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSendBulkRetriesTransientFailures(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"results":[{"success":true},{"success":false,"error":"duplicate"}]}`))
+	}))
+	defer srv.Close()
+
+	defer func(url string, backoff time.Duration) {
+		BackendBulkURL, BackendRetryBackoff = url, backoff
+	}(BackendBulkURL, BackendRetryBackoff)
+	BackendBulkURL = srv.URL
+	BackendRetryBackoff = time.Millisecond
+
+	errs := SendBulk(context.Background(), []map[string]interface{}{{"title": "a"}, {"title": "b"}})
+	if requests != 2 {
+		t.Errorf("got %d requests, want 2", requests)
+	}
+	if errs[0] != nil {
+		t.Errorf("item 0: %v", errs[0])
+	}
+	if errs[1] == nil || errs[1].Error() != "duplicate" {
+		t.Errorf("item 1: got %v, want duplicate", errs[1])
+	}
+}
@@ -0,0 +1,155 @@
+package output
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// gcsScope is the OAuth2 scope GCS uploads need.
+const gcsScope = "https://www.googleapis.com/auth/devstorage.read_write"
+
+// GCSCredentials, when set, replaces GCSAccessToken with tokens minted from
+// a service account key, so uploads keep working past the hour a token
+// from gcloud lasts.
+var GCSCredentials *ServiceAccount
+
+// ServiceAccount is a Google service account JSON key. Its access tokens
+// come from the JWT bearer grant and are cached until shortly before they
+// expire.
+type ServiceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	TokenURI     string `json:"token_uri"`
+
+	key     *rsa.PrivateKey
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// LoadServiceAccount reads the service account key file at path.
+func LoadServiceAccount(path string) (*ServiceAccount, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sa ServiceAccount
+	if err := json.Unmarshal(data, &sa); err != nil {
+		return nil, err
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, errors.New("key has no client_email or private_key")
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	block, _ := pem.Decode([]byte(sa.PrivateKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return nil, fmt.Errorf("could not parse private_key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	sa.key = key
+	return &sa, nil
+}
+
+// Token returns the cached access token, requesting a new one when it is
+// about to expire.
+func (sa *ServiceAccount) Token(ctx context.Context) (string, error) {
+	sa.mu.Lock()
+	defer sa.mu.Unlock()
+	if sa.token != "" && time.Now().Before(sa.expires) {
+		return sa.token, nil
+	}
+
+	assertion, err := sa.assertion(time.Now())
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", sa.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := externalClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("service account token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("service account token endpoint returned %d: %s", resp.StatusCode, body)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.AccessToken == "" {
+		return "", fmt.Errorf("service account token endpoint sent no access_token: %s", body)
+	}
+	lifetime := time.Duration(result.ExpiresIn) * time.Second
+	if lifetime <= 0 {
+		lifetime = time.Hour
+	}
+	sa.token = result.AccessToken
+	sa.expires = time.Now().Add(lifetime - min(oauthMargin, lifetime/2))
+	return sa.token, nil
+}
+
+// invalidate drops the cached token after GCS rejected it.
+func (sa *ServiceAccount) invalidate() {
+	sa.mu.Lock()
+	sa.token = ""
+	sa.mu.Unlock()
+}
+
+// assertion is the signed RS256 JWT exchanged for an access token.
+func (sa *ServiceAccount) assertion(now time.Time) (string, error) {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": sa.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   sa.ClientEmail,
+		"scope": gcsScope,
+		"aud":   sa.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	signing := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signing))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, sa.key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signing + "." + enc.EncodeToString(sig), nil
+}
@@ -0,0 +1,76 @@
+package output
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestServiceAccountToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		parts := strings.Split(r.PostForm.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("assertion has %d parts", len(parts))
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+			t.Errorf("bad assertion signature: %v", err)
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		var c map[string]interface{}
+		json.Unmarshal(claims, &c)
+		if c["iss"] != "uploader@example.iam.gserviceaccount.com" || c["scope"] != gcsScope {
+			t.Errorf("unexpected claims %s", claims)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "tok", "expires_in": 3600})
+	}))
+	defer srv.Close()
+
+	keyFile, _ := json.Marshal(map[string]string{
+		"client_email": "uploader@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    srv.URL,
+	})
+	path := filepath.Join(t.TempDir(), "key.json")
+	os.WriteFile(path, keyFile, 0o600)
+
+	sa, err := LoadServiceAccount(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if token, err := sa.Token(context.Background()); err != nil || token != "tok" {
+			t.Fatalf("Token() = %q, %v", token, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("token endpoint called %d times, want 1 while the token is valid", requests)
+	}
+	sa.invalidate()
+	sa.Token(context.Background())
+	if requests != 2 {
+		t.Errorf("token endpoint called %d times after invalidate, want 2", requests)
+	}
+}
@@ -0,0 +1,284 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	// Outputs are the destinations every payload is sent to, unless a job
+	// lists its own. Publishers come on top of them.
+	Outputs = []string{"backend"}
+
+	WebhookURL       string
+	WebhookMethod    = "POST"
+	WebhookHeaders   map[string]string
+	WebhookToken     string
+	WebhookBasicAuth string
+
+	SlackWebhookURL   string
+	DiscordWebhookURL string
+
+	RabbitMQURL        string
+	RabbitMQExchange   string
+	RabbitMQRoutingKey = "prompts"
+)
+
+var outputNames = []string{"backend", "queue", "webhook", "slack", "discord", "rabbitmq"}
+
+// ParseOutputs parses a comma-separated OUTPUTS list.
+func ParseOutputs(s string) ([]string, error) {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, n := range outputNames {
+			known = known || n == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown output %q (want %s)", part, strings.Join(outputNames, ", "))
+		}
+		out = appendUnique(out, name)
+	}
+	return out, nil
+}
+
+// buildOutput returns the sink for an output, or an error naming the
+// setting it is missing. backend is the job's backend URL, which main
+// checks itself since other modes run without one.
+func buildOutput(name, backend string) (Sink, error) {
+	switch name {
+	case "backend":
+		return backendSink{url: backend}, nil
+	case "queue":
+		if QueueURL == "" {
+			return nil, errors.New("the queue output needs QUEUE_URL")
+		}
+		return httpQueueSink{url: QueueURL}, nil
+	case "webhook":
+		if WebhookURL == "" {
+			return nil, errors.New("the webhook output needs WEBHOOK_URL")
+		}
+		return webhookSink{}, nil
+	case "slack":
+		if SlackWebhookURL == "" {
+			return nil, errors.New("the slack output needs SLACK_WEBHOOK_URL")
+		}
+		return slackSink{}, nil
+	case "discord":
+		if DiscordWebhookURL == "" {
+			return nil, errors.New("the discord output needs DISCORD_WEBHOOK_URL")
+		}
+		return discordSink{}, nil
+	case "rabbitmq":
+		if RabbitMQURL == "" {
+			return nil, errors.New("the rabbitmq output needs RABBITMQ_URL")
+		}
+		return sharedRabbitMQ, nil
+	}
+	return nil, fmt.Errorf("unknown output %q", name)
+}
+
+// HasOutput reports whether outputs includes name.
+func HasOutput(outputs []string, name string) bool {
+	for _, o := range outputs {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+// sendHTTP makes one request and fails on any non-2xx status.
+func sendHTTP(ctx context.Context, name, method, url string, body []byte, header http.Header) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	runctx.SetRunIDHeader(ctx, req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s rejected data (%d): %s", name, resp.StatusCode, respBody)
+	}
+	return nil
+}
+
+// webhookSink sends the payload to WEBHOOK_URL with WEBHOOK_METHOD, extra
+// WEBHOOK_HEADERS and a bearer token or user:password basic credentials.
+type webhookSink struct{}
+
+func (webhookSink) Name() string { return "webhook" }
+
+func (webhookSink) Send(ctx context.Context, payload []byte) error {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	for k, v := range WebhookHeaders {
+		header.Set(k, v)
+	}
+	if WebhookToken != "" {
+		header.Set("Authorization", "Bearer "+WebhookToken)
+	}
+	if WebhookBasicAuth != "" {
+		header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(WebhookBasicAuth)))
+	}
+	return sendHTTP(ctx, "webhook", WebhookMethod, WebhookURL, payload, header)
+}
+
+// slackSink announces the prompt through a Slack incoming webhook.
+type slackSink struct{}
+
+func (slackSink) Name() string { return "slack" }
+
+func (slackSink) Send(ctx context.Context, payload []byte) error {
+	p, err := decodeSocialPost(payload)
+	if err != nil {
+		return err
+	}
+	text := "*" + strings.TrimSpace(p.Title) + "*"
+	if d := strings.TrimSpace(p.Description); d != "" {
+		text += "\n" + d
+	}
+	if len(p.Tags) > 0 {
+		text += "\n`" + strings.Join(p.Tags, "` `") + "`"
+	}
+	body, _ := json.Marshal(map[string]string{"text": text})
+	return sendHTTP(ctx, "slack", "POST", SlackWebhookURL, body, http.Header{"Content-Type": {"application/json"}})
+}
+
+// discordSink announces the prompt as an embed through a Discord webhook.
+type discordSink struct{}
+
+func (discordSink) Name() string { return "discord" }
+
+func (discordSink) Send(ctx context.Context, payload []byte) error {
+	p, err := decodeSocialPost(payload)
+	if err != nil {
+		return err
+	}
+	// Embed titles are capped at 256 characters and descriptions at 4096.
+	embed := map[string]interface{}{
+		"title":       truncateRunes(strings.TrimSpace(p.Title), 256),
+		"description": truncateRunes(strings.TrimSpace(p.Description), 4096),
+	}
+	if len(p.Tags) > 0 {
+		embed["footer"] = map[string]string{"text": truncateRunes(strings.Join(p.Tags, " · "), 2048)}
+	}
+	body, _ := json.Marshal(map[string]interface{}{"embeds": []interface{}{embed}})
+	return sendHTTP(ctx, "discord", "POST", DiscordWebhookURL, body, http.Header{"Content-Type": {"application/json"}})
+}
+
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
+
+// rabbitMQSink publishes persistent messages to RABBITMQ_EXCHANGE with
+// RABBITMQ_ROUTING_KEY, waiting for the broker to confirm each one. The
+// connection is opened on first use and reopened after a failure.
+type rabbitMQSink struct {
+	mu   sync.Mutex
+	conn *amqp.Connection
+	ch   *amqp.Channel
+}
+
+var sharedRabbitMQ = &rabbitMQSink{}
+
+func (s *rabbitMQSink) Name() string { return "rabbitmq" }
+
+func (s *rabbitMQSink) Send(ctx context.Context, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ch == nil || s.ch.IsClosed() {
+		if err := s.connect(); err != nil {
+			return fmt.Errorf("could not connect to RabbitMQ: %w", err)
+		}
+	}
+
+	confirm, err := s.ch.PublishWithDeferredConfirmWithContext(ctx, RabbitMQExchange, RabbitMQRoutingKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    runctx.RunID(ctx),
+		Body:         payload,
+	})
+	if err != nil {
+		s.close()
+		return err
+	}
+	ok, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("RabbitMQ did not accept the message")
+	}
+	return nil
+}
+
+func (s *rabbitMQSink) connect() error {
+	s.close()
+	conn, err := amqp.Dial(RabbitMQURL)
+	if err != nil {
+		return err
+	}
+	ch, err := conn.Channel()
+	if err == nil {
+		err = ch.Confirm(false)
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	s.conn, s.ch = conn, ch
+	return nil
+}
+
+func (s *rabbitMQSink) close() {
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.conn, s.ch = nil, nil
+}
+
+// appendUnique appends the non-empty items not already in list.
+func appendUnique(list []string, items ...string) []string {
+	for _, item := range items {
+		if item == "" {
+			continue
+		}
+		dup := false
+		for _, existing := range list {
+			if strings.EqualFold(existing, item) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			list = append(list, item)
+		}
+	}
+	return list
+}
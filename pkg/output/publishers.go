@@ -0,0 +1,270 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+const (
+	LinkedInEndpoint = "https://api.linkedin.com/v2/ugcPosts"
+	XEndpoint        = "https://api.twitter.com/2/tweets"
+)
+
+var (
+	// Publishers are the social platforms every prompt is also posted to,
+	// unless a job lists its own.
+	Publishers []string
+
+	LinkedInAccessToken string
+	// LinkedInAuthor is the person or organization URN posts are made as,
+	// e.g. urn:li:person:abc123.
+	LinkedInAuthor string
+
+	// XAccessToken is an OAuth 2.0 user-context token with tweet.write.
+	XAccessToken string
+
+	MastodonURL         string
+	MastodonAccessToken string
+	MastodonCharLimit   = 500
+)
+
+var publisherNames = []string{"linkedin", "x", "mastodon"}
+
+// ParsePublishers parses a comma-separated PUBLISHERS list, accepting
+// "twitter" for x.
+func ParsePublishers(s string) ([]string, error) {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		name := strings.ToLower(strings.TrimSpace(part))
+		if name == "" {
+			continue
+		}
+		if name == "twitter" {
+			name = "x"
+		}
+		if !validPublisher(name) {
+			return nil, fmt.Errorf("unknown publisher %q (want %s)", part, strings.Join(publisherNames, ", "))
+		}
+		out = appendUnique(out, name)
+	}
+	return out, nil
+}
+
+func validPublisher(name string) bool {
+	for _, n := range publisherNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// buildPublisher returns the sink for a platform, or an error naming the
+// credentials it is missing.
+func buildPublisher(name string) (Sink, error) {
+	switch name {
+	case "linkedin":
+		if LinkedInAccessToken == "" || LinkedInAuthor == "" {
+			return nil, errors.New("linkedin needs LINKEDIN_ACCESS_TOKEN and LINKEDIN_AUTHOR")
+		}
+		return linkedInSink{}, nil
+	case "x":
+		if XAccessToken == "" {
+			return nil, errors.New("x needs X_ACCESS_TOKEN")
+		}
+		return xSink{}, nil
+	case "mastodon":
+		if MastodonURL == "" || MastodonAccessToken == "" {
+			return nil, errors.New("mastodon needs MASTODON_URL and MASTODON_ACCESS_TOKEN")
+		}
+		return mastodonSink{}, nil
+	}
+	return nil, fmt.Errorf("unknown publisher %q", name)
+}
+
+// socialPost is the part of a payload a social post is made from.
+type socialPost struct {
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+}
+
+func decodeSocialPost(payload []byte) (socialPost, error) {
+	var p socialPost
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return p, err
+	}
+	if strings.TrimSpace(p.Title) == "" && strings.TrimSpace(p.Description) == "" {
+		return p, errors.New("payload has no title or description to post")
+	}
+	return p, nil
+}
+
+// formatPost renders the title, description and hashtags within limit
+// characters. Hashtags that do not fit are dropped first, then the
+// description is shortened with an ellipsis; withDescription=false leaves
+// it out altogether.
+func formatPost(p socialPost, limit int, withDescription bool) string {
+	head := strings.TrimSpace(p.Title)
+	body := ""
+	if withDescription {
+		body = strings.TrimSpace(p.Description)
+	}
+
+	var tags []string
+	for _, t := range p.Tags {
+		if tag := hashtag(t); tag != "" {
+			tags = appendUnique(tags, tag)
+		}
+	}
+
+	join := func(head, body string, tags []string) string {
+		parts := []string{}
+		for _, s := range []string{head, body, strings.Join(tags, " ")} {
+			if s != "" {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, "\n\n")
+	}
+
+	text := join(head, body, tags)
+	for len(tags) > 0 && utf8.RuneCountInString(text) > limit {
+		tags = tags[:len(tags)-1]
+		text = join(head, body, tags)
+	}
+	if over := utf8.RuneCountInString(text) - limit; over > 0 && body != "" {
+		runes := []rune(body)
+		keep := len(runes) - over - 1
+		if keep > 0 {
+			body = strings.TrimSpace(string(runes[:keep])) + "…"
+		} else {
+			body = ""
+		}
+		text = join(head, body, tags)
+	}
+	if runes := []rune(text); len(runes) > limit {
+		text = string(runes[:limit-1]) + "…"
+	}
+	return text
+}
+
+// hashtag turns a tag into a hashtag, dropping characters hashtags cannot
+// contain.
+func hashtag(tag string) string {
+	var b strings.Builder
+	for _, r := range tag {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "#" + b.String()
+}
+
+// postSocial sends one JSON request to a platform API. Posts are not
+// retried: a request that timed out may still have been published.
+func postSocial(ctx context.Context, platform, url, token string, body interface{}, headers map[string]string) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	runctx.SetRunIDHeader(ctx, req)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s rejected post (%d): %s", platform, resp.StatusCode, respBody)
+	}
+	runctx.Logf(ctx, "📣 Published to %s", platform)
+	return nil
+}
+
+// linkedInSink shares the prompt as a public LinkedIn post.
+type linkedInSink struct{}
+
+func (linkedInSink) Name() string { return "linkedin" }
+
+func (linkedInSink) Send(ctx context.Context, payload []byte) error {
+	p, err := decodeSocialPost(payload)
+	if err != nil {
+		return err
+	}
+	body := map[string]interface{}{
+		"author":         LinkedInAuthor,
+		"lifecycleState": "PUBLISHED",
+		"specificContent": map[string]interface{}{
+			"com.linkedin.ugc.ShareContent": map[string]interface{}{
+				"shareCommentary":    map[string]string{"text": formatPost(p, 3000, true)},
+				"shareMediaCategory": "NONE",
+			},
+		},
+		"visibility": map[string]string{"com.linkedin.ugc.MemberNetworkVisibility": "PUBLIC"},
+	}
+	return postSocial(ctx, "LinkedIn", LinkedInEndpoint, LinkedInAccessToken, body, map[string]string{
+		"X-Restli-Protocol-Version": "2.0.0",
+	})
+}
+
+// xSink posts the prompt as a tweet. 280 characters leave little room, so
+// the description is only kept when it fits alongside the title.
+type xSink struct{}
+
+func (xSink) Name() string { return "x" }
+
+func (xSink) Send(ctx context.Context, payload []byte) error {
+	p, err := decodeSocialPost(payload)
+	if err != nil {
+		return err
+	}
+	text := formatPost(p, 280, true)
+	if strings.HasSuffix(text, "…") {
+		text = formatPost(p, 280, false)
+	}
+	return postSocial(ctx, "X", XEndpoint, XAccessToken, map[string]string{"text": text}, nil)
+}
+
+// mastodonSink posts a public status to the MASTODON_URL instance. The
+// run ID is sent as the idempotency key so a repeated send is not posted
+// twice.
+type mastodonSink struct{}
+
+func (mastodonSink) Name() string { return "mastodon" }
+
+func (mastodonSink) Send(ctx context.Context, payload []byte) error {
+	p, err := decodeSocialPost(payload)
+	if err != nil {
+		return err
+	}
+	body := map[string]string{"status": formatPost(p, MastodonCharLimit, true), "visibility": "public"}
+	var headers map[string]string
+	if id := runctx.RunID(ctx); id != "" {
+		headers = map[string]string{"Idempotency-Key": id}
+	}
+	return postSocial(ctx, "Mastodon", strings.TrimRight(MastodonURL, "/")+"/api/v1/statuses", MastodonAccessToken, body, headers)
+}
@@ -0,0 +1,131 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payloads held for approval are announced on ReviewChannel: "slack",
+// "webhook", "email", or "none" to leave them for the admin API.
+var (
+	ReviewChannel = "none"
+
+	ReviewSlackWebhookURL string
+	ReviewWebhookURL      string
+
+	SMTPHost     string
+	SMTPPort     = 587
+	SMTPUser     string
+	SMTPPassword string
+	ReviewFrom   string
+	ReviewTo     []string
+)
+
+// Review is one payload awaiting approval, as announced to reviewers.
+type Review struct {
+	ID          string          `json:"id"`
+	Job         string          `json:"job,omitempty"`
+	Title       string          `json:"title"`
+	Description string          `json:"description,omitempty"`
+	ApproveURL  string          `json:"approveUrl,omitempty"`
+	RejectURL   string          `json:"rejectUrl,omitempty"`
+	Expires     time.Time       `json:"expires"`
+	Payload     json.RawMessage `json:"payload"`
+}
+
+// CheckReviewChannel returns an error naming the setting ReviewChannel is
+// missing.
+func CheckReviewChannel() error {
+	switch ReviewChannel {
+	case "none":
+	case "slack":
+		if ReviewSlackWebhookURL == "" {
+			return errors.New("the slack approval channel needs APPROVAL_SLACK_WEBHOOK_URL or SLACK_WEBHOOK_URL")
+		}
+	case "webhook":
+		if ReviewWebhookURL == "" {
+			return errors.New("the webhook approval channel needs APPROVAL_WEBHOOK_URL")
+		}
+	case "email":
+		if SMTPHost == "" || ReviewFrom == "" || len(ReviewTo) == 0 {
+			return errors.New("the email approval channel needs SMTP_HOST, APPROVAL_EMAIL_FROM and APPROVAL_EMAIL_TO")
+		}
+	default:
+		return fmt.Errorf("unknown approval channel %q (want none, slack, webhook, email)", ReviewChannel)
+	}
+	return nil
+}
+
+// SendReview announces r on ReviewChannel.
+func SendReview(ctx context.Context, r Review) error {
+	ctx, cancel := context.WithTimeout(ctx, BackendTimeout)
+	defer cancel()
+
+	switch ReviewChannel {
+	case "slack":
+		body, _ := json.Marshal(map[string]string{"text": reviewText(r, "*"+r.Title+"*")})
+		return sendHTTP(ctx, "slack", "POST", ReviewSlackWebhookURL, body, http.Header{"Content-Type": {"application/json"}})
+	case "webhook":
+		body, _ := json.Marshal(r)
+		return sendHTTP(ctx, "approval webhook", "POST", ReviewWebhookURL, body, http.Header{"Content-Type": {"application/json"}})
+	case "email":
+		return sendReviewEmail(r)
+	}
+	return nil
+}
+
+func reviewText(r Review, heading string) string {
+	text := "📝 Awaiting approval: " + heading
+	if r.Job != "" {
+		text += " (job " + r.Job + ")"
+	}
+	if d := strings.TrimSpace(r.Description); d != "" {
+		text += "\n" + d
+	}
+	if r.ApproveURL != "" {
+		text += "\nApprove: " + r.ApproveURL + "\nReject: " + r.RejectURL
+	} else {
+		text += "\nDecide through the admin API: /approvals/" + r.ID
+	}
+	return text + "\nExpires " + r.Expires.Format(time.RFC1123)
+}
+
+// sendReviewEmail mails the review with net/smtp, which upgrades to TLS
+// when the server offers STARTTLS.
+func sendReviewEmail(r Review) error {
+	var auth smtp.Auth
+	if SMTPUser != "" {
+		auth = smtp.PlainAuth("", SMTPUser, SMTPPassword, SMTPHost)
+	}
+	pretty, _ := json.MarshalIndent(r.Payload, "", "  ")
+	msg := "From: " + ReviewFrom + "\r\n" +
+		"To: " + strings.Join(ReviewTo, ", ") + "\r\n" +
+		"Subject: " + mimeHeader("Approval needed: "+r.Title) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n\r\n" +
+		strings.ReplaceAll(reviewText(r, r.Title)+"\n\n"+string(pretty)+"\n", "\n", "\r\n")
+	addr := SMTPHost + ":" + strconv.Itoa(SMTPPort)
+	if err := smtp.SendMail(addr, auth, ReviewFrom, ReviewTo, []byte(msg)); err != nil {
+		return fmt.Errorf("email: %w", err)
+	}
+	return nil
+}
+
+// mimeHeader encodes non-ASCII header text as an RFC 2047 word.
+func mimeHeader(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	for _, c := range s {
+		if c > 127 {
+			return mime.QEncoding.Encode("utf-8", s)
+		}
+	}
+	return s
+}
@@ -0,0 +1,368 @@
+// Package output delivers payloads to the backend, queues, webhooks and
+// social platforms.
+package output
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"promptcraft-groq/internal/metrics"
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/internal/runctx"
+)
+
+var (
+	BackendAPI string
+	Breakers   = &retry.Breakers{Name: "Backend"}
+
+	QueueURL string
+	Latency  = metrics.NewHistogram()
+)
+
+var (
+	backendCooldown        retry.Cooldown
+	BackendDefaultCooldown = 30 * time.Second
+)
+
+// Sink is a destination for generated prompt payloads.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, payload []byte) error
+}
+
+type backendSink struct {
+	url string
+}
+
+func (s backendSink) Name() string { return "backend" }
+
+func (s backendSink) Send(ctx context.Context, payload []byte) error {
+	contentType := BackendContentType
+	if BackendEncoding == "form" {
+		form, err := formEncode(payload)
+		if err != nil {
+			return err
+		}
+		payload = form
+		if contentType == "application/json" {
+			contentType = "application/x-www-form-urlencoded"
+		}
+	}
+
+	breaker := Breakers.For(s.url)
+	post := func() error {
+		return breaker.Call(isTransientBackendError, func() error {
+			return s.post(ctx, contentType, payload)
+		})
+	}
+	return retry.Do(ctx, "Backend request failed", BackendMaxRetries, BackendRetryBackoff, isTransientBackendError, func() error {
+		return retry.Do(ctx, "Backend soft error", BackendSoftRetries, BackendSoftBackoff, isSoftError, func() error {
+			err := post()
+			if err == errBackendRateLimited {
+				// One more try once the shared cooldown has elapsed.
+				runctx.Logln(ctx, "🔁 Retrying backend send after 429 cooldown")
+				err = post()
+			}
+			return err
+		})
+	})
+}
+
+// backendStatusError is a non-200 backend response other than a 429.
+type backendStatusError struct {
+	status int
+	body   string
+}
+
+func (e *backendStatusError) Error() string {
+	return fmt.Sprintf("backend rejected data (%d): %s", e.status, e.body)
+}
+
+// isTransientBackendError retries network errors and 500/502/503/504, but
+// not rejected payloads nor the run being cancelled.
+func isTransientBackendError(err error) bool {
+	var statusErr *backendStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	var netErr *url.Error
+	return errors.As(err, &netErr) && !errors.Is(err, context.Canceled)
+}
+
+// backendSoftError is a 200 response whose body reports a failure through
+// BACKEND_SUCCESS_FIELD.
+type backendSoftError struct {
+	body string
+}
+
+func (e *backendSoftError) Error() string {
+	return "backend reported failure in a 200 response: " + e.body
+}
+
+func isSoftError(err error) bool {
+	var soft *backendSoftError
+	return errors.As(err, &soft)
+}
+
+// checkSuccessField reports a soft error unless the JSON body has a truthy
+// BACKEND_SUCCESS_FIELD.
+func checkSuccessField(body []byte) error {
+	var envelope map[string]interface{}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return &backendSoftError{body: string(body)}
+	}
+	switch v := envelope[BackendSuccessField].(type) {
+	case bool:
+		if v {
+			return nil
+		}
+	case string:
+		if ok, _ := strconv.ParseBool(v); ok || strings.EqualFold(v, "ok") {
+			return nil
+		}
+	case float64:
+		if v != 0 {
+			return nil
+		}
+	}
+	return &backendSoftError{body: string(body)}
+}
+
+// formEncode turns a JSON object into URL-encoded fields. Nested arrays
+// and objects are JSON-stringified into a single field.
+func formEncode(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("form encoding: %w", err)
+	}
+
+	values := url.Values{}
+	for k, raw := range fields {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			values.Set(k, s)
+		} else if string(raw) != "null" {
+			values.Set(k, string(raw))
+		}
+	}
+	return []byte(values.Encode()), nil
+}
+
+var errBackendRateLimited = errors.New("backend rate limited (429)")
+
+func (s backendSink) post(ctx context.Context, contentType string, payload []byte) error {
+	if err := backendCooldown.Wait(ctx, "backend"); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := postBackend(ctx, s.url, contentType, payload)
+	if err != nil {
+		Latency.Observe("error", time.Since(start))
+		return err
+	}
+	defer resp.Body.Close()
+	Latency.Observe(strconv.Itoa(resp.StatusCode), time.Since(start))
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		wait, ok := retry.ParseRetryAfter(resp.Header.Get("Retry-After"))
+		if !ok {
+			wait = BackendDefaultCooldown
+		}
+		runctx.Logf(ctx, "🚦 Backend returned 429, pausing backend sends for %s", wait.Round(time.Second))
+		backendCooldown.Extend(wait)
+		return errBackendRateLimited
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &backendStatusError{status: resp.StatusCode, body: string(body)}
+	}
+	if BackendSuccessField != "" {
+		body, _ := io.ReadAll(resp.Body)
+		return checkSuccessField(body)
+	}
+	return nil
+}
+
+// postBackend posts with the backend credentials, as long as url is on the
+// host of BACKEND_API_URL, BACKEND_BULK_URL or BACKEND_UPLOAD_URL; a job
+// backend elsewhere gets none. A 401 with an OAuth2 token fetches a fresh
+// token and tries once more.
+func postBackend(ctx context.Context, url, contentType string, payload []byte) (*http.Response, error) {
+	if !backendHost(url) {
+		runctx.Logf(ctx, "🔑 %s is not on a backend host, sending no backend credentials", url)
+		return postJSON(ctx, backendClient, url, contentType, nil, payload)
+	}
+	for attempt := 0; ; attempt++ {
+		header, err := backendHeader(ctx)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := postJSON(ctx, backendClient, url, contentType, header, payload)
+		if err != nil || resp.StatusCode != http.StatusUnauthorized || attempt > 0 || !invalidateOAuthToken() {
+			return resp, err
+		}
+		resp.Body.Close()
+		runctx.Logln(ctx, "🔑 Backend rejected the OAuth token, fetching a new one")
+	}
+}
+
+// httpQueueSink publishes payloads to an HTTP-fronted message queue,
+// e.g. a NATS or RabbitMQ HTTP bridge.
+type httpQueueSink struct {
+	url string
+}
+
+func (s httpQueueSink) Name() string { return "queue" }
+
+func (s httpQueueSink) Send(ctx context.Context, payload []byte) error {
+	resp, err := postJSON(ctx, externalClient, s.url, "application/json", nil, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("queue rejected data (%d): %s", resp.StatusCode, body)
+	}
+	return nil
+}
+
+var Sinks []Sink
+
+// BuildSinks returns the named outputs, with the backend at url, followed
+// by the named social publishers.
+func BuildSinks(url string, outputs, publishers []string) []Sink {
+	var out []Sink
+	for _, name := range outputs {
+		o, err := buildOutput(name, url)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		out = append(out, o)
+	}
+	for _, name := range publishers {
+		p, err := buildPublisher(name)
+		if err != nil {
+			log.Fatal("❌ ", err)
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// BackendOnly reports whether sinks is just the backend, which is when
+// bulk sends can replace them.
+func BackendOnly(sinks []Sink) bool {
+	return len(sinks) == 1 && sinks[0].Name() == "backend"
+}
+
+const maxBackendRedirects = 10
+
+var (
+	BackendRedirects   = "follow"
+	BackendContentType = "application/json"
+	BackendAPIToken    string
+	BackendEncoding    = "json"
+
+	// BackendSuccessField names a body field that must be truthy for a 200
+	// to count as success; failures are retried BackendSoftRetries times.
+	BackendSuccessField string
+	BackendSoftRetries  = 2
+	BackendSoftBackoff  = time.Second
+
+	// BackendMaxRetries covers network errors and 5xx responses.
+	BackendMaxRetries   = 2
+	BackendRetryBackoff = time.Second
+
+	// backendClient never follows redirects itself: net/http would turn a
+	// redirected POST into a GET and drop the body.
+	backendClient = &http.Client{CheckRedirect: noRedirects}
+
+	// externalClient is for the queue and object stores, which must not
+	// be sent the backend's TLS client certificate.
+	externalClient = &http.Client{CheckRedirect: noRedirects}
+)
+
+func noRedirects(req *http.Request, via []*http.Request) error {
+	return http.ErrUseLastResponse
+}
+
+// ForceBackendHTTP1 stops backend calls from negotiating HTTP/2, working
+// around backends whose HTTP/2 support stalls.
+func ForceBackendHTTP1() {
+	t := backendTransport()
+	t.ForceAttemptHTTP2 = false
+	t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+}
+
+// SetBackendTimeout sets BackendTimeout. It caps the client as well, so a
+// backend call can never hang even without a per-stage context.
+func SetBackendTimeout(d time.Duration) {
+	BackendTimeout = d
+	backendClient.Timeout = d
+	externalClient.Timeout = d
+}
+
+// postJSON POSTs the payload, re-sending it with the same method and body
+// when a redirect is followed, or failing on redirects when
+// BACKEND_REDIRECTS=error. header carries the credentials, which are not
+// sent to a redirect target on another host.
+func postJSON(ctx context.Context, client *http.Client, url, contentType string, header http.Header, payload []byte) (*http.Response, error) {
+	for hops := 0; ; hops++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		req.Header.Set("Content-Type", contentType)
+		runctx.SetRunIDHeader(ctx, req)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 300 || resp.StatusCode >= 400 {
+			return resp, nil
+		}
+
+		location, err := resp.Location()
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("redirect (%s) without a usable Location: %w", resp.Status, err)
+		}
+		if BackendRedirects == "error" {
+			runctx.Logf(ctx, "↪️ %s redirected to %s", url, location)
+			return nil, fmt.Errorf("refusing to follow redirect (%s) to %s", resp.Status, location)
+		}
+		if hops >= maxBackendRedirects {
+			return nil, fmt.Errorf("stopped after %d redirects", maxBackendRedirects)
+		}
+		runctx.Logf(ctx, "↪️ Following redirect (%s) to %s", resp.Status, location)
+		if location.Host != req.URL.Host {
+			header = nil
+		}
+		url = location.String()
+	}
+}
+
+var BackendTimeout = 20 * time.Second
@@ -0,0 +1,199 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/sigv4"
+)
+
+// Generated images are uploaded to IMAGE_STORAGE: the backend's upload
+// endpoint, an S3 (or S3-compatible) bucket or a GCS bucket.
+var (
+	ImageStorage = "backend"
+
+	// BackendUploadURL takes a multipart "file" field and answers with a
+	// JSON body holding the URL in BackendUploadURLField.
+	BackendUploadURL      string
+	BackendUploadURLField = "url"
+
+	S3Bucket          string
+	S3Region          = "us-east-1"
+	S3Endpoint        string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+	S3SessionToken    string
+
+	GCSBucket      string
+	GCSAccessToken string
+
+	// ImagePublicURL replaces the bucket's own URL in the returned link,
+	// e.g. a CDN in front of it.
+	ImagePublicURL string
+)
+
+// UploadImage stores data under name and returns its public URL.
+func UploadImage(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	ctx, cancel := runctx.WithStageTimeout(ctx, BackendTimeout)
+	defer cancel()
+
+	switch ImageStorage {
+	case "backend":
+		return uploadToBackend(ctx, name, contentType, data)
+	case "s3":
+		return uploadToS3(ctx, name, contentType, data)
+	case "gcs":
+		return uploadToGCS(ctx, name, contentType, data)
+	}
+	return "", fmt.Errorf("unknown image storage %q", ImageStorage)
+}
+
+func uploadToBackend(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	if BackendUploadURL == "" {
+		return "", fmt.Errorf("BACKEND_UPLOAD_URL not set")
+	}
+	var form bytes.Buffer
+	w := multipart.NewWriter(&form)
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, name))
+	header.Set("Content-Type", contentType)
+	part, _ := w.CreatePart(header)
+	part.Write(data)
+	w.Close()
+
+	resp, err := postBackend(ctx, BackendUploadURL, w.FormDataContentType(), form.Bytes())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("backend upload rejected image (%d): %s", resp.StatusCode, body)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", fmt.Errorf("could not parse backend upload response: %w", err)
+	}
+	link, _ := result[BackendUploadURLField].(string)
+	if link == "" {
+		return "", fmt.Errorf("backend upload response has no %q: %s", BackendUploadURLField, body)
+	}
+	return link, nil
+}
+
+// uploadToS3 PUTs the object with a SigV4-signed request. With S3_ENDPOINT
+// the bucket is addressed path-style, as MinIO and most S3-compatible
+// stores expect.
+func uploadToS3(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	if S3Bucket == "" || S3AccessKeyID == "" || S3SecretAccessKey == "" {
+		return "", fmt.Errorf("S3_BUCKET, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	objectURL := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", S3Bucket, S3Region, escapePath(name))
+	if S3Endpoint != "" {
+		objectURL = strings.TrimRight(S3Endpoint, "/") + "/" + S3Bucket + "/" + escapePath(name)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", objectURL, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	sigv4.Sign(req, data, "s3", S3Region, sigv4.Credentials{AccessKeyID: S3AccessKeyID, SecretAccessKey: S3SecretAccessKey, SessionToken: S3SessionToken}, time.Now())
+
+	if err := doUpload(req, "S3"); err != nil {
+		return "", err
+	}
+	return publicImageURL(objectURL, name), nil
+}
+
+// uploadToGCS uses the JSON API's simple media upload with an OAuth2
+// access token: one minted from GCSCredentials, or GCSAccessToken, e.g.
+// from gcloud auth print-access-token.
+func uploadToGCS(ctx context.Context, name, contentType string, data []byte) (string, error) {
+	if GCSBucket == "" || (GCSAccessToken == "" && GCSCredentials == nil) {
+		return "", fmt.Errorf("GCS_BUCKET and GCS_CREDENTIALS_FILE or GCS_ACCESS_TOKEN must be set")
+	}
+	uploadURL := "https://storage.googleapis.com/upload/storage/v1/b/" + url.PathEscape(GCSBucket) +
+		"/o?uploadType=media&name=" + url.QueryEscape(name)
+
+	for attempt := 0; ; attempt++ {
+		token := GCSAccessToken
+		if GCSCredentials != nil {
+			var err error
+			if token, err = GCSCredentials.Token(ctx); err != nil {
+				return "", err
+			}
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", uploadURL, bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		err = doUpload(req, "GCS")
+		var statusErr *uploadStatusError
+		if errors.As(err, &statusErr) && statusErr.status == http.StatusUnauthorized && GCSCredentials != nil && attempt == 0 {
+			runctx.Logln(ctx, "🔑 GCS rejected the service account token, fetching a new one")
+			GCSCredentials.invalidate()
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return publicImageURL("https://storage.googleapis.com/"+GCSBucket+"/"+escapePath(name), name), nil
+	}
+}
+
+// uploadStatusError is a non-2xx answer from an object store.
+type uploadStatusError struct {
+	name   string
+	status int
+	body   string
+}
+
+func (e *uploadStatusError) Error() string {
+	return fmt.Sprintf("%s rejected image (%d): %s", e.name, e.status, e.body)
+}
+
+func doUpload(req *http.Request, name string) error {
+	runctx.SetRunIDHeader(req.Context(), req)
+	resp, err := externalClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return &uploadStatusError{name: name, status: resp.StatusCode, body: string(body)}
+	}
+	return nil
+}
+
+func publicImageURL(objectURL, name string) string {
+	if ImagePublicURL == "" {
+		return objectURL
+	}
+	return strings.TrimRight(ImagePublicURL, "/") + "/" + escapePath(name)
+}
+
+// escapePath escapes each segment of an object key, keeping the slashes.
+func escapePath(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,154 @@
+package scheduler
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"promptcraft-groq/pkg/generator"
+)
+
+// AdminToken enables the admin API (/jobs, /runs/latest and /approvals)
+// and protects /generate. Requests must send it as a bearer token. Without
+// it /generate is open, as it was before the admin API existed.
+var AdminToken string
+
+// adminOnly rejects requests without the admin token.
+func adminOnly(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAdmin(r) {
+			denyAdmin(w)
+			return
+		}
+		h(w, r)
+	}
+}
+
+func isAdmin(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(AdminToken)) == 1
+}
+
+func denyAdmin(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="autopost admin"`)
+	http.Error(w, "missing or wrong admin token", http.StatusUnauthorized)
+}
+
+// jobStatus is one job as listed by GET /jobs.
+type jobStatus struct {
+	Name     string     `json:"name"`
+	Schedule string     `json:"schedule"`
+	NextRun  time.Time  `json:"nextRun"`
+	Paused   bool       `json:"paused"`
+	LastRun  *RunRecord `json:"lastRun,omitempty"`
+}
+
+func statusOf(j *Job) jobStatus {
+	s := jobStatus{Name: j.Label(), Schedule: j.Schedule, NextRun: j.Next(time.Now()), Paused: j.Paused()}
+	if rec, ok := lastRun(j.Name); ok {
+		s.LastRun = &rec
+	}
+	return s
+}
+
+// jobsHandler serves GET /jobs: every scheduled job with its next and last
+// run.
+func jobsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	jobs := []jobStatus{}
+	for _, j := range ScheduledJobs() {
+		jobs = append(jobs, statusOf(j))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jobs": jobs})
+}
+
+// jobHandler serves POST /jobs/{name}/run, /pause and /resume. The job
+// built from CRON_SCHEDULE is called "default".
+func jobHandler(w http.ResponseWriter, r *http.Request) {
+	name, action, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, "/jobs/"), "/")
+	if !ok || name == "" || strings.Contains(action, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	var job *Job
+	for _, j := range ScheduledJobs() {
+		if j.Label() == name {
+			job = j
+		}
+	}
+	if job == nil {
+		http.Error(w, "unknown job", http.StatusNotFound)
+		return
+	}
+	if action != "run" && action != "pause" && action != "resume" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch action {
+	case "pause":
+		job.Pause()
+		log.Printf("⏸️ Job %q paused through the admin API", name)
+	case "resume":
+		job.Resume()
+		log.Printf("▶️ Job %q resumed through the admin API", name)
+	case "run":
+		runJobNow(w, r, job)
+		return
+	}
+	writeJSON(w, http.StatusOK, statusOf(job))
+}
+
+// runJobNow runs one batch of the job on demand, sharing the
+// HTTP_GENERATE_CONCURRENCY slots with /generate. Paused jobs run too.
+func runJobNow(w http.ResponseWriter, r *http.Request, j *Job) {
+	select {
+	case generateSlots <- struct{}{}:
+		defer func() { <-generateSlots }()
+	default:
+		tooManyGenerations(w)
+		return
+	}
+
+	log.Printf("⚡ Job %q triggered through the admin API", j.Label())
+	failed := RunBatch(generator.WithJob(r.Context(), j.Job))
+	status := http.StatusOK
+	if failed > 0 {
+		status = http.StatusBadGateway
+	}
+	writeJSON(w, status, map[string]interface{}{"job": statusOf(j), "failed": failed})
+}
+
+// latestRunHandler serves GET /runs/latest: the last payload handed to the
+// outputs, with the run it came from.
+func latestRunHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	p := generator.LastPayload()
+	if p == nil {
+		http.Error(w, "no payload generated yet", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
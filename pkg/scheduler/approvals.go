@@ -0,0 +1,204 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/approval"
+	"promptcraft-groq/pkg/generator"
+)
+
+// decideMu serializes decisions so an item is never delivered twice.
+var decideMu sync.Mutex
+
+// approvalsHandler serves GET /approvals, the items awaiting review, or
+// every item with ?status=.
+func approvalsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = approval.Pending
+	} else if status == "all" {
+		status = ""
+	}
+	items := approval.Queue.List(status)
+	for i := range items {
+		items[i].Token = ""
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"approvals": items})
+}
+
+// approvalHandler serves GET /approvals/{id} for admins and
+// /approvals/{id}/approve or /reject. Those take the admin token or the
+// link token sent to reviewers; a GET with the link token shows a
+// confirmation form, so link previews in chat apps decide nothing.
+func approvalHandler(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/approvals/"), "/")
+	it, ok := approval.Queue.Get(id)
+	if !ok || strings.Contains(action, "/") {
+		http.NotFound(w, r)
+		return
+	}
+	admin := AdminToken != "" && isAdmin(r)
+	token := r.FormValue("token")
+
+	switch {
+	case action == "":
+		if !admin {
+			denyAdmin(w)
+			return
+		}
+		it.Token = ""
+		writeJSON(w, http.StatusOK, it)
+		return
+	case action != "approve" && action != "reject":
+		http.NotFound(w, r)
+		return
+	case !admin && !it.CheckToken(token):
+		http.Error(w, "missing or wrong approval token", http.StatusForbidden)
+		return
+	}
+
+	if r.Method == http.MethodGet && !admin {
+		confirmPage(w, it, action, token)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	it, err := decide(r.Context(), id, action == "approve")
+	status := http.StatusOK
+	switch {
+	case err == errNotOpen:
+		status = http.StatusConflict
+	case err != nil:
+		status = http.StatusBadGateway
+	}
+	if admin {
+		it.Token = ""
+		writeJSON(w, status, it)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	msg := fmt.Sprintf("%q is %s.", it.Title, it.Status)
+	if err != nil {
+		msg += " " + err.Error()
+	}
+	fmt.Fprintf(w, "<!doctype html><title>autopost approval</title><p>%s</p>\n", html.EscapeString(msg))
+}
+
+func confirmPage(w http.ResponseWriter, it approval.Item, action, token string) {
+	pretty, _ := json.MarshalIndent(it.Payload, "", "  ")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, `<!doctype html><title>autopost approval</title>
+<h1>%s</h1>
+<p>Status: %s, expires %s</p>
+<pre>%s</pre>
+<form method="post"><input type="hidden" name="token" value="%s"><button>%s</button></form>
+`, html.EscapeString(it.Title), it.Status, it.Expires.Format(time.RFC1123), html.EscapeString(string(pretty)),
+		html.EscapeString(token), strings.ToUpper(action[:1])+action[1:])
+}
+
+var errNotOpen = errors.New("it was already decided or has expired")
+
+// decide rejects the item or delivers its payload to the outputs of the
+// job it was generated by. A failed delivery leaves it open for another
+// approval, which only sends to the outputs that failed.
+func decide(ctx context.Context, id string, approve bool) (approval.Item, error) {
+	decideMu.Lock()
+	defer decideMu.Unlock()
+
+	approval.Queue.Sweep(time.Now())
+	it, _ := approval.Queue.Get(id)
+	if !it.Open() {
+		return it, errNotOpen
+	}
+	if !approve {
+		it, _ = approval.Queue.Settle(id, approval.Rejected, "", nil)
+		log.Printf("🚫 Rejected %q (%s)", it.Title, id)
+		return it, nil
+	}
+
+	if failed, err := deliverApproved(ctx, it); err != nil {
+		if failed == nil {
+			failed = it.Sinks
+		}
+		log.Printf("❌ Approved %q (%s) but could not send it to %s: %v", it.Title, id, strings.Join(failed, ", "), err)
+		it, _ = approval.Queue.Settle(id, approval.Failed, err.Error(), failed)
+		return it, err
+	}
+	generator.MarkApproved(runctx.WithRunID(ctx, it.RunID), it.Entry)
+	it, _ = approval.Queue.Settle(id, approval.Approved, "", nil)
+	log.Printf("✅ Approved and sent %q (%s)", it.Title, id)
+	return it, nil
+}
+
+// deliverApproved sends the item's payload to the outputs it has not
+// reached yet and returns those that rejected it.
+func deliverApproved(ctx context.Context, it approval.Item) ([]string, error) {
+	generator.Reconfigure.RLock()
+	defer generator.Reconfigure.RUnlock()
+	generator.SendMu.Lock()
+	defer generator.SendMu.Unlock()
+
+	ctx = runctx.WithRunID(ctx, it.RunID)
+	if it.Job != "" {
+		j := FindJob(it.Job)
+		if j == nil {
+			return nil, fmt.Errorf("job %q is no longer configured", it.Job)
+		}
+		ctx = generator.WithJob(ctx, j.Job)
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(it.Payload, &payload); err != nil {
+		return nil, fmt.Errorf("could not decode stored payload: %w", err)
+	}
+	// The timestamp is the send time, which is now.
+	generator.StampPayload(payload)
+	jsonPayload, _ := json.Marshal(payload)
+	var only []string
+	if len(it.Sinks) > 0 {
+		only = it.Sinks
+	}
+	d := generator.Deliver(ctx, jsonPayload, only)
+	return d.Failed(), d.Err()
+}
+
+// startApprovalSweeper expires unreviewed items every minute until the
+// shutdown begins.
+func startApprovalSweeper() {
+	if !approval.Enabled {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-shutdownStarted:
+				return
+			}
+			for _, it := range approval.Queue.Sweep(time.Now()) {
+				log.Printf("⌛ %q (%s) expired without a review", it.Title, it.ID)
+			}
+		}
+	}()
+}
@@ -0,0 +1,58 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/generator"
+)
+
+// BatchSummary totals the runs of a Batch.
+type BatchSummary struct {
+	Runs      int
+	Succeeded int
+	// BySector counts successful runs per sector, ByStage failed runs per
+	// stage they failed in.
+	BySector map[string]int
+	ByStage  map[string]int
+	Elapsed  time.Duration
+}
+
+// Failed is the number of runs that did not succeed.
+func (s BatchSummary) Failed() int { return s.Runs - s.Succeeded }
+
+// Batch generates count prompts for the context's job with up to
+// concurrency in flight. Unlike RunBatch it is not clamped to MAX_PER_RUN
+// and always sends one by one, so every run has its own record.
+func Batch(ctx context.Context, count, concurrency int) BatchSummary {
+	sum := BatchSummary{BySector: map[string]int{}, ByStage: map[string]int{}}
+	locales := generator.LocalesFrom(ctx)
+	if len(locales) == 1 {
+		ctx = generator.WithLocale(ctx, locales[0])
+	}
+
+	start := time.Now()
+	var mu sync.Mutex
+	forEachConcurrent(ctx, count, concurrency, func() {
+		recs := runLocalized(ctx)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rec := range recs {
+			sum.Runs++
+			if rec.Status == "success" {
+				sum.Succeeded++
+				sum.BySector[rec.Sector]++
+			} else {
+				sum.ByStage[rec.Stage]++
+			}
+		}
+	})
+	sum.Elapsed = time.Since(start)
+
+	if sum.Succeeded > 0 {
+		LastSuccess.Set(jobLabel(runctx.JobName(ctx)), start)
+	}
+	return sum
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+)
+
+var (
+	BackendBulk bool
+)
+
+// runBulk generates count prompts and posts the successful ones to
+// BACKEND_BULK_URL as a single JSON array. It returns how many were saved.
+func runBulk(ctx context.Context, count int) int {
+	type pending struct {
+		runID  string
+		start  time.Time
+		prompt generator.PromptResponse
+	}
+
+	var batch []pending
+	// The dedup store only learns about prompts once they are sent, so
+	// duplicates within the batch are caught here.
+	duplicateInBatch := func(p generator.PromptResponse, batch []pending) string {
+		for _, b := range batch {
+			if generator.Similarity(p.Title, p.Prompt, b.prompt.Title, b.prompt.Prompt) >= generator.DedupThreshold {
+				return b.prompt.Title
+			}
+		}
+		return ""
+	}
+	var mu sync.Mutex
+	forEachConcurrent(ctx, count, MaxConcurrency, func() {
+		p := pending{runID: runctx.NewRunID(), start: time.Now()}
+		prompt, err := generator.GenerateAndSend(generator.WithDeferredSend(runctx.WithRunID(ctx, p.runID)))
+		if err != nil {
+			finishRun(ctx, p.runID, p.start, prompt, err)
+			return
+		}
+		p.prompt = prompt
+
+		mu.Lock()
+		defer mu.Unlock()
+		if dupOf := duplicateInBatch(prompt, batch); dupOf != "" {
+			runctx.Logf(ctx, "⏭️ %q duplicates %q from this batch, skipping", prompt.Title, dupOf)
+			finishRun(ctx, p.runID, p.start, prompt, generator.InStage("dedup", generator.ErrDuplicate))
+			return
+		}
+		batch = append(batch, p)
+	})
+	if len(batch) == 0 {
+		return 0
+	}
+
+	generator.Reconfigure.RLock()
+	defer generator.Reconfigure.RUnlock()
+	payloads := make([]map[string]interface{}, len(batch))
+	for i, p := range batch {
+		runCtx := runctx.WithRunID(ctx, p.runID)
+		payloads[i] = generator.BuildPayload(runCtx, p.prompt)
+		generator.StampPayload(payloads[i])
+		if payload, err := json.Marshal(payloads[i]); err == nil {
+			generator.RecordPayload(runCtx, payload)
+		}
+	}
+
+	runctx.Logf(ctx, "📦 Sending %d prompt(s) in one bulk request", len(batch))
+	errs := output.SendBulk(ctx, payloads)
+
+	saved := 0
+	for i, p := range batch {
+		err := errs[i]
+		if err != nil {
+			runctx.Logf(ctx, "❌ Bulk item %d (%q) failed: %v", i, p.prompt.Title, err)
+			if payload, jsonErr := json.Marshal(payloads[i]); jsonErr == nil {
+				err = &generator.UnsentError{Payload: payload, Err: err}
+			}
+			err = generator.InStage("backend", err)
+		} else {
+			generator.MarkSent(ctx, p.prompt)
+			saved++
+		}
+		finishRun(ctx, p.runID, p.start, p.prompt, err)
+	}
+	runctx.Logf(ctx, "✅ Bulk request saved %d of %d prompt(s)", saved, len(batch))
+	return saved
+}
@@ -0,0 +1,107 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/state"
+)
+
+// CatchUpWindow turns the startup run into a catch-up: only jobs that
+// missed a scheduled run within this long before startup run then, once
+// each however many runs they missed. 0 runs every job at startup.
+var CatchUpWindow time.Duration
+
+// RunTimes remembers when each job last had a successful batch, keyed by
+// job label, so missed runs can be told apart after a restart.
+type RunTimes struct {
+	mu    sync.Mutex
+	times map[string]time.Time
+	file  *state.File
+}
+
+var LastSuccess = &RunTimes{times: map[string]time.Time{}}
+
+// LoadRunTimes reads the last successful run times at path.
+func LoadRunTimes(path string) *RunTimes {
+	t := &RunTimes{times: map[string]time.Time{}}
+	t.file = state.Register(path, func() ([]byte, error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return json.MarshalIndent(t.times, "", "  ")
+	})
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &t.times); err != nil {
+			log.Println("⚠️ Could not parse schedule state, starting empty:", err)
+			t.times = map[string]time.Time{}
+		}
+	} else if !os.IsNotExist(err) {
+		log.Println("⚠️ Could not read schedule state:", err)
+	}
+	return t
+}
+
+// Get returns when the job last ran successfully, or the zero time.
+func (t *RunTimes) Get(job string) time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.times[job]
+}
+
+// Set records a successful batch of the job that started at at.
+func (t *RunTimes) Set(job string, at time.Time) {
+	t.mu.Lock()
+	if at.After(t.times[job]) {
+		t.times[job] = at
+	}
+	t.mu.Unlock()
+	if t.file != nil {
+		t.file.MarkDirty()
+	}
+}
+
+// missedRun returns the job's latest scheduled time within CatchUpWindow
+// before now that came after its last successful run.
+func missedRun(j *Job, now time.Time) (time.Time, bool) {
+	var last time.Time
+	// A schedule firing every minute needs 1440 steps per day of window;
+	// the cap only guards against a window of years.
+	for t, i := j.Next(now.Add(-CatchUpWindow)), 0; !t.IsZero() && !t.After(now) && i < 100000; t, i = j.Next(t), i+1 {
+		last = t
+	}
+	if last.IsZero() || !LastSuccess.Get(j.Label()).Before(last) {
+		return time.Time{}, false
+	}
+	return last, true
+}
+
+// startupJobs returns the jobs to run at startup: those with RunAtStartup,
+// or with CATCHUP_WINDOW those that missed a run.
+func startupJobs(jobs []*Job) []*Job {
+	if CatchUpWindow <= 0 {
+		var out []*Job
+		for _, j := range jobs {
+			if j.RunAtStartup {
+				out = append(out, j)
+			}
+		}
+		return out
+	}
+	now := time.Now()
+	var out []*Job
+	for _, j := range jobs {
+		if missed, ok := missedRun(j, now); ok {
+			log.Printf("⏰ Job %q missed its run at %s, catching up", j.Label(), missed.Format(time.RFC3339))
+			out = append(out, j)
+		}
+	}
+	if len(out) == 0 {
+		log.Printf("⏭️ No scheduled run missed in the last %s, skipping the startup run", CatchUpWindow)
+	}
+	return out
+}
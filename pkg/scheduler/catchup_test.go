@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"promptcraft-groq/pkg/generator"
+)
+
+func TestStartupJobs(t *testing.T) {
+	defer func(window time.Duration, last *RunTimes) {
+		CatchUpWindow, LastSuccess = window, last
+	}(CatchUpWindow, LastSuccess)
+
+	hourly, err := ParseSchedule("0 * * * *", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job := func(name string, atStartup bool) *Job {
+		return &Job{Job: &generator.Job{Name: name}, RunAtStartup: atStartup, schedule: hourly}
+	}
+	jobs := []*Job{job("a", true), job("b", false), job("c", false)}
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		last   map[string]time.Time
+		want   []string
+	}{
+		{name: "no catch-up runs startup jobs", window: 0, want: []string{"a"}},
+		{
+			name:   "catch-up runs jobs that missed a run",
+			window: 3 * time.Hour,
+			last:   map[string]time.Time{"a": now, "b": now.Add(-2 * time.Hour)},
+			want:   []string{"b", "c"},
+		},
+		{
+			name:   "catch-up skips jobs that ran since",
+			window: 3 * time.Hour,
+			last:   map[string]time.Time{"a": now, "b": now, "c": now},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			CatchUpWindow = tt.window
+			LastSuccess = &RunTimes{times: map[string]time.Time{}}
+			for name, at := range tt.last {
+				LastSuccess.Set(name, at)
+			}
+			var got []string
+			for _, j := range startupJobs(jobs) {
+				got = append(got, j.Label())
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("startupJobs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/pkg/llm"
+	"promptcraft-groq/pkg/output"
+)
+
+var HealthPort = "8080"
+
+// runCounters holds process-lifetime run counters for /healthz and /metrics.
+type runCounters struct {
+	mu sync.Mutex
+
+	totalRuns       int
+	successes       int
+	llmFailures     int
+	backendFailures int
+	failuresByStage map[string]int
+
+	lastRunTime     time.Time
+	lastRunOK       bool
+	lastSuccessTime time.Time
+}
+
+var runMetrics = &runCounters{failuresByStage: map[string]int{}}
+
+func (m *runCounters) Record(rec RunRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.totalRuns++
+	m.lastRunTime = rec.Timestamp
+	m.lastRunOK = rec.Status == "success"
+	if m.lastRunOK {
+		m.successes++
+		m.lastSuccessTime = rec.Timestamp
+		return
+	}
+	m.failuresByStage[rec.Stage]++
+	switch rec.Stage {
+	case "llm", "extraction":
+		m.llmFailures++
+	case "backend":
+		m.backendFailures++
+	}
+}
+
+func healthzHandler(w http.ResponseWriter, r *http.Request) {
+	runMetrics.mu.Lock()
+	resp := map[string]interface{}{
+		"status":    "ok",
+		"lastRunOK": runMetrics.lastRunOK,
+	}
+	if !runMetrics.lastRunTime.IsZero() {
+		resp["lastRunTime"] = runMetrics.lastRunTime
+	}
+	runMetrics.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// schedulerReady is set once the scheduler is running.
+var schedulerReady atomic.Bool
+
+// readyzHandler reports 503 until the scheduler has started, during a
+// shutdown, and while the circuit breaker of any model or backend endpoint
+// has paused its calls.
+func readyzHandler(w http.ResponseWriter, r *http.Request) {
+	var problems []string
+	if !schedulerReady.Load() {
+		problems = append(problems, "scheduler not started")
+	}
+	if ShuttingDown() {
+		problems = append(problems, "shutting down")
+	}
+	for _, b := range breakers() {
+		if b.Open() {
+			problems = append(problems, strings.ToLower(b.Name)+" circuit open")
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "not ready", "reasons": problems})
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"status": "ready"})
+}
+
+// breakers returns the model and backend circuit breakers, one per
+// endpoint that has been called.
+func breakers() []*retry.Breaker {
+	return append(llm.Breakers.All(), output.Breakers.All()...)
+}
+
+// metricsHandler serves the Prometheus text format, or the JSON counters
+// for ?format=json and Accept: application/json.
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		metricsJSONHandler(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	runMetrics.mu.Lock()
+	fmt.Fprintf(w, "# HELP autopost_runs_total Generate-and-send runs attempted.\n# TYPE autopost_runs_total counter\nautopost_runs_total %d\n", runMetrics.totalRuns)
+	fmt.Fprintf(w, "# HELP autopost_run_successes_total Runs whose prompt reached the backend.\n# TYPE autopost_run_successes_total counter\nautopost_run_successes_total %d\n", runMetrics.successes)
+	fmt.Fprintf(w, "# HELP autopost_run_failures_total Failed runs by the stage they failed in.\n# TYPE autopost_run_failures_total counter\n")
+	stages := make([]string, 0, len(runMetrics.failuresByStage))
+	for s := range runMetrics.failuresByStage {
+		stages = append(stages, s)
+	}
+	sort.Strings(stages)
+	for _, s := range stages {
+		fmt.Fprintf(w, "autopost_run_failures_total{stage=%q} %d\n", s, runMetrics.failuresByStage[s])
+	}
+	fmt.Fprintf(w, "# HELP autopost_last_run_timestamp_seconds Start time of the most recent run.\n# TYPE autopost_last_run_timestamp_seconds gauge\nautopost_last_run_timestamp_seconds %d\n", unixOrZero(runMetrics.lastRunTime))
+	fmt.Fprintf(w, "# HELP autopost_last_success_timestamp_seconds Start time of the most recent successful run.\n# TYPE autopost_last_success_timestamp_seconds gauge\nautopost_last_success_timestamp_seconds %d\n", unixOrZero(runMetrics.lastSuccessTime))
+	runMetrics.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP autopost_circuit_open Whether the circuit breaker of a model or backend endpoint is open.\n# TYPE autopost_circuit_open gauge\n")
+	for _, set := range []*retry.Breakers{llm.Breakers, output.Breakers} {
+		for _, b := range set.All() {
+			open := 0
+			if b.Open() {
+				open = 1
+			}
+			fmt.Fprintf(w, "autopost_circuit_open{upstream=%q,endpoint=%q} %d\n", strings.ToLower(set.Name), b.Endpoint, open)
+		}
+	}
+
+	llm.Usage.WriteMetrics(w)
+	llm.Latency.Write(w, "autopost_llm_request_duration_seconds", "Duration of single model API requests.", "provider")
+	output.Latency.Write(w, "autopost_backend_request_duration_seconds", "Duration of single backend requests.", "status")
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func metricsJSONHandler(w http.ResponseWriter, r *http.Request) {
+	runMetrics.mu.Lock()
+	resp := map[string]interface{}{
+		"totalRuns":       runMetrics.totalRuns,
+		"successes":       runMetrics.successes,
+		"llmFailures":     runMetrics.llmFailures,
+		"backendFailures": runMetrics.backendFailures,
+		// groqFailures is the old name of llmFailures.
+		"groqFailures": runMetrics.llmFailures,
+	}
+	runMetrics.mu.Unlock()
+	resp["tokensToday"] = llm.Usage.Today()
+	circuits := map[string]bool{}
+	for _, b := range breakers() {
+		circuits[b.Name] = b.Open()
+	}
+	resp["circuitsOpen"] = circuits
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
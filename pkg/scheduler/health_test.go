@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"promptcraft-groq/internal/retry"
+	"promptcraft-groq/pkg/output"
+)
+
+func TestReadyz(t *testing.T) {
+	defer func(ready bool, breakers *retry.Breakers, threshold int) {
+		schedulerReady.Store(ready)
+		output.Breakers, retry.CircuitBreakerThreshold = breakers, threshold
+	}(schedulerReady.Load(), output.Breakers, retry.CircuitBreakerThreshold)
+	retry.CircuitBreakerThreshold = 1
+
+	tests := []struct {
+		name        string
+		ready       bool
+		openBackend bool
+		wantStatus  int
+		wantReasons []string
+	}{
+		{name: "scheduler not started", wantStatus: http.StatusServiceUnavailable, wantReasons: []string{"scheduler not started"}},
+		{name: "ready", ready: true, wantStatus: http.StatusOK},
+		{
+			name: "backend circuit open", ready: true, openBackend: true,
+			wantStatus: http.StatusServiceUnavailable, wantReasons: []string{"backend https://api.example.com/prompts circuit open"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedulerReady.Store(tt.ready)
+			output.Breakers = &retry.Breakers{Name: "Backend"}
+			if tt.openBackend {
+				output.Breakers.For("https://api.example.com/prompts").Record(true)
+			}
+
+			rec := httptest.NewRecorder()
+			readyzHandler(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Reasons []string `json:"reasons"`
+			}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if !reflect.DeepEqual(body.Reasons, tt.wantReasons) {
+				t.Errorf("reasons = %q, want %q", body.Reasons, tt.wantReasons)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/generator"
+)
+
+var (
+	RunHistoryFile   = "run_history.json"
+	RunHistoryMaxAge = 7 * 24 * time.Hour
+
+	// ReplayInterval is how often the daemon re-sends failed payloads; 0
+	// leaves them for "autopost replay".
+	ReplayInterval time.Duration
+)
+
+// historyEntry is one run as kept in the run history. Payload is only kept
+// while the run's payload still has to reach its outputs.
+type historyEntry struct {
+	RunID     string                    `json:"runId"`
+	Job       string                    `json:"job,omitempty"`
+	Timestamp time.Time                 `json:"timestamp"`
+	Status    string                    `json:"status"`
+	Stage     string                    `json:"stage,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+	Prompt    *generator.PromptResponse `json:"prompt,omitempty"`
+	Payload   json.RawMessage           `json:"payload,omitempty"`
+	// Sinks are the outputs the payload still has to reach; empty means
+	// all of them, as in histories written before it was tracked. Sent
+	// means the primary output already has it.
+	Sinks   []string `json:"sinks,omitempty"`
+	Sent    bool     `json:"sent,omitempty"`
+	Replays int      `json:"replays,omitempty"`
+}
+
+// RunHistory persists every run with its prompt for RunHistoryMaxAge, so
+// payloads that could not be sent survive a restart and can be replayed.
+type RunHistory struct {
+	mu      sync.Mutex
+	entries []historyEntry
+	file    *state.File
+}
+
+var History *RunHistory
+
+// LoadRunHistory reads the history at path; an empty path keeps it in
+// memory only.
+func LoadRunHistory(path string) *RunHistory {
+	h := &RunHistory{}
+	if path == "" {
+		return h
+	}
+	h.file = state.Register(path, h.marshal)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("⚠️ Could not read run history:", err)
+		}
+		return h
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		log.Println("⚠️ Could not parse run history, starting empty:", err)
+	}
+	return h
+}
+
+func (h *RunHistory) marshal() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return json.MarshalIndent(h.entries, "", "  ")
+}
+
+func (h *RunHistory) changed() {
+	if h.file != nil {
+		h.file.MarkDirty()
+	}
+}
+
+// pruneLocked drops entries older than RunHistoryMaxAge.
+func (h *RunHistory) pruneLocked() {
+	if RunHistoryMaxAge <= 0 {
+		return
+	}
+	cutoff := time.Now().Add(-RunHistoryMaxAge)
+	kept := h.entries[:0]
+	for _, e := range h.entries {
+		if e.Timestamp.After(cutoff) {
+			kept = append(kept, e)
+		}
+	}
+	h.entries = kept
+}
+
+// Record adds a finished run, along with its payload if it failed to send.
+func (h *RunHistory) Record(rec RunRecord, p generator.PromptResponse, err error) {
+	e := historyEntry{RunID: rec.ID, Job: rec.Job, Timestamp: rec.Timestamp, Status: rec.Status, Stage: rec.Stage, Error: rec.Error}
+	if p.Title != "" || p.Prompt != "" {
+		e.Prompt = &p
+	}
+
+	if payload := generator.UnsentPayload(err); payload != nil && (rec.Stage == "backend" || rec.Status == "success") {
+		e.Payload = payload
+		e.Sinks = generator.UnsentSinks(err)
+		e.Sent = generator.Delivered(err)
+	}
+
+	h.mu.Lock()
+	h.entries = append(h.entries, e)
+	h.pruneLocked()
+	h.mu.Unlock()
+	h.changed()
+}
+
+// pending returns the entries whose payload still has to be sent.
+func (h *RunHistory) pending() []historyEntry {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.pruneLocked()
+	var out []historyEntry
+	for _, e := range h.entries {
+		if e.Payload != nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (h *RunHistory) update(runID string, fn func(*historyEntry)) {
+	h.mu.Lock()
+	for i := range h.entries {
+		if h.entries[i].RunID == runID {
+			fn(&h.entries[i])
+		}
+	}
+	h.mu.Unlock()
+	h.changed()
+}
+
+// ReplayFailed re-sends every payload in the history that did not reach
+// all its outputs, to just the outputs that rejected it. Prompts that were
+// never sent and duplicate one sent in the meantime are dropped. It
+// returns how many were sent and how many are still pending.
+func ReplayFailed(ctx context.Context) (sent, pending int) {
+	for _, e := range History.pending() {
+		if ctx.Err() != nil || ShuttingDown() {
+			break
+		}
+		runCtx := runctx.WithRunID(ctx, e.RunID)
+		if e.Job != "" {
+			j := FindJob(e.Job)
+			if j == nil {
+				runctx.Logf(runCtx, "⚠️ Job %q no longer exists, leaving its payload in the history", e.Job)
+				continue
+			}
+			runCtx = generator.WithJob(runCtx, j.Job)
+		}
+
+		if replayEntry(runCtx, e) {
+			sent++
+		}
+	}
+	return sent, len(History.pending())
+}
+
+// replayEntry re-sends one entry's payload under generator.SendMu, like a regular
+// send, and reports whether it reached every output. Replays go straight
+// to the outputs, without APPROVAL_MODE: the payload was already on its way
+// out when it failed.
+func replayEntry(ctx context.Context, e historyEntry) bool {
+	generator.Reconfigure.RLock()
+	defer generator.Reconfigure.RUnlock()
+	generator.SendMu.Lock()
+	defer generator.SendMu.Unlock()
+
+	if e.Prompt != nil && !e.Sent {
+		if match, _, dup := generator.Seen.FindSimilar(*e.Prompt); dup {
+			runctx.Logf(ctx, "⏭️ Not replaying %q, %q has been sent since", e.Prompt.Title, match.Title)
+			History.update(e.RunID, func(e *historyEntry) { e.Status, e.Payload, e.Sinks = "duplicate", nil, nil })
+			return false
+		}
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(e.Payload, &payload); err != nil {
+		runctx.Logln(ctx, "⚠️ Could not decode stored payload, dropping it:", err)
+		History.update(e.RunID, func(e *historyEntry) { e.Error, e.Payload, e.Sinks = err.Error(), nil, nil })
+		return false
+	}
+	generator.StampPayload(payload)
+	jsonPayload, _ := json.Marshal(payload)
+
+	var only []string
+	if len(e.Sinks) > 0 {
+		only = e.Sinks
+	}
+	d := generator.Deliver(ctx, jsonPayload, only)
+	err := d.Err()
+	sent := !e.Sent && (err == nil || d.Sent())
+	History.update(e.RunID, func(e *historyEntry) {
+		e.Replays++
+		if err != nil {
+			e.Error, e.Sinks = err.Error(), d.Failed()
+			e.Sent = e.Sent || sent
+			return
+		}
+		e.Status, e.Stage, e.Error, e.Payload, e.Sinks = "replayed", "", "", nil, nil
+	})
+	if sent && e.Prompt != nil {
+		generator.MarkSent(ctx, *e.Prompt)
+	}
+	if err != nil {
+		runctx.Logln(ctx, "❌ Replay failed:", err)
+		return false
+	}
+	runctx.Logln(ctx, "✅ Replayed payload from", e.Timestamp.Format(time.RFC3339))
+	return true
+}
+
+// startReplayWorker re-sends failed payloads every ReplayInterval until
+// the shutdown begins.
+func startReplayWorker(ctx context.Context) {
+	if ReplayInterval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(ReplayInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-shutdownStarted:
+				return
+			}
+			if len(History.pending()) == 0 {
+				continue
+			}
+			if sent, pending := ReplayFailed(ctx); sent > 0 {
+				log.Printf("🔁 Replay: %d payload(s) sent, %d still pending", sent, pending)
+			}
+		}
+	}()
+}
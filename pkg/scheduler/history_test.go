@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+)
+
+// recordingSink records the payloads it is sent and fails with err.
+type recordingSink struct {
+	name string
+	err  error
+	got  []map[string]interface{}
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Send(ctx context.Context, payload []byte) error {
+	var m map[string]interface{}
+	json.Unmarshal(payload, &m)
+	s.got = append(s.got, m)
+	return s.err
+}
+
+func TestReplayFailed(t *testing.T) {
+	defer func(sinks []output.Sink, seen *generator.SeenStore, history *RunHistory) {
+		output.Sinks, generator.Seen, History = sinks, seen, history
+	}(output.Sinks, generator.Seen, History)
+
+	sent := generator.PromptResponse{Title: "Launch plan", Prompt: "Write a launch plan for {product}."}
+	fresh := generator.PromptResponse{Title: "Churn email", Prompt: "Draft a win-back email for {customer}."}
+
+	tests := []struct {
+		name       string
+		entry      historyEntry
+		webhookErr error
+		wantSent   int
+		wantCalls  map[string]int
+		wantStatus string
+		wantSinks  []string
+		wantKept   bool
+	}{
+		{
+			name:       "only the failed sinks",
+			entry:      historyEntry{Prompt: &fresh, Sinks: []string{"webhook"}, Sent: true},
+			wantSent:   1,
+			wantCalls:  map[string]int{"backend": 0, "webhook": 1},
+			wantStatus: "replayed",
+		},
+		{
+			name:       "every sink when none are recorded",
+			entry:      historyEntry{Prompt: &fresh},
+			wantSent:   1,
+			wantCalls:  map[string]int{"backend": 1, "webhook": 1},
+			wantStatus: "replayed",
+		},
+		{
+			name:       "duplicate of a prompt sent since",
+			entry:      historyEntry{Prompt: &sent},
+			wantCalls:  map[string]int{"backend": 0, "webhook": 0},
+			wantStatus: "duplicate",
+		},
+		{
+			name:       "already sent prompts skip the duplicate check",
+			entry:      historyEntry{Prompt: &sent, Sinks: []string{"webhook"}, Sent: true},
+			wantSent:   1,
+			wantCalls:  map[string]int{"backend": 0, "webhook": 1},
+			wantStatus: "replayed",
+		},
+		{
+			name:       "failing sink stays pending",
+			entry:      historyEntry{Prompt: &fresh},
+			webhookErr: errors.New("webhook down"),
+			wantCalls:  map[string]int{"backend": 1, "webhook": 1},
+			wantStatus: "failed",
+			wantSinks:  []string{"webhook"},
+			wantKept:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &recordingSink{name: "backend"}
+			webhook := &recordingSink{name: "webhook", err: tt.webhookErr}
+			output.Sinks = []output.Sink{backend, webhook}
+			generator.Seen = generator.LoadSeenStore(filepath.Join(t.TempDir(), "seen.json"), 10)
+			generator.Seen.Add(sent)
+
+			e := tt.entry
+			e.RunID, e.Timestamp, e.Status, e.Stage = "run-1", time.Now(), "failed", "backend"
+			e.Payload, _ = json.Marshal(map[string]interface{}{"title": e.Prompt.Title, "prompt": e.Prompt.Prompt})
+			History = LoadRunHistory("")
+			History.entries = []historyEntry{e}
+
+			gotSent, gotPending := ReplayFailed(context.Background())
+			if gotSent != tt.wantSent {
+				t.Errorf("sent = %d, want %d", gotSent, tt.wantSent)
+			}
+			wantPending := 0
+			if tt.wantKept {
+				wantPending = 1
+			}
+			if gotPending != wantPending {
+				t.Errorf("pending = %d, want %d", gotPending, wantPending)
+			}
+			gotCalls := map[string]int{"backend": len(backend.got), "webhook": len(webhook.got)}
+			if !reflect.DeepEqual(gotCalls, tt.wantCalls) {
+				t.Errorf("sink calls = %v, want %v", gotCalls, tt.wantCalls)
+			}
+			got := History.entries[0]
+			if got.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", got.Status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(got.Sinks, tt.wantSinks) {
+				t.Errorf("sinks = %v, want %v", got.Sinks, tt.wantSinks)
+			}
+			if (got.Payload != nil) != tt.wantKept {
+				t.Errorf("payload kept = %v, want %v", got.Payload != nil, tt.wantKept)
+			}
+		})
+	}
+}
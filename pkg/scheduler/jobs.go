@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+	"promptcraft-groq/pkg/sources"
+)
+
+var (
+	CronSchedule    = "0 9 * * *"
+	DefaultSchedule cron.Schedule
+
+	// Timezone is the IANA zone schedules without their own CRON_TZ=
+	// prefix or job timezone run in; empty means local time.
+	Timezone string
+)
+
+// ParseSchedule parses a standard cron expression in the tz zone, unless
+// the expression names its own with a CRON_TZ= or TZ= prefix.
+func ParseSchedule(spec, tz string) (cron.Schedule, error) {
+	if tz != "" && !strings.HasPrefix(spec, "CRON_TZ=") && !strings.HasPrefix(spec, "TZ=") {
+		spec = "CRON_TZ=" + tz + " " + spec
+	}
+	return cron.ParseStandard(spec)
+}
+
+// Job is a generator job with the cron schedule it runs on.
+type Job struct {
+	*generator.Job
+	Schedule string
+	// Timezone replaces CRON_TZ for the job when set.
+	Timezone string
+	// RunAtStartup runs the job when the worker starts without a
+	// CATCHUP_WINDOW; the default job always does.
+	RunAtStartup bool
+
+	schedule cron.Schedule
+	paused   atomic.Bool
+}
+
+// Next returns the job's next run after t.
+func (j *Job) Next(t time.Time) time.Time { return j.schedule.Next(t) }
+
+// Pause stops the job's scheduled runs until Resume; it can still be run
+// on demand.
+func (j *Job) Pause() { j.paused.Store(true) }
+
+func (j *Job) Resume() { j.paused.Store(false) }
+
+func (j *Job) Paused() bool { return j.paused.Load() }
+
+// Label names the job in logs and the admin API, "default" for the job
+// built from CRON_SCHEDULE.
+func (j *Job) Label() string { return jobLabel(j.Name) }
+
+func jobLabel(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// Jobs are declared under "jobs" in the config file. Without any, the
+// process runs a single job built from CRON_SCHEDULE and the other
+// settings.
+var Jobs []*Job
+
+// JobConfig is one entry of the config file's "jobs" list.
+type JobConfig struct {
+	Name     string `json:"name" yaml:"name"`
+	Schedule string `json:"schedule" yaml:"schedule"`
+	Timezone string `json:"timezone" yaml:"timezone"`
+	Template string `json:"template" yaml:"template"`
+	Model    string `json:"model" yaml:"model"`
+	Backend  string `json:"backend" yaml:"backend"`
+
+	Outputs    []string `json:"outputs" yaml:"outputs"`
+	Publishers []string `json:"publishers" yaml:"publishers"`
+	Locales    []string `json:"locales" yaml:"locales"`
+
+	ContentType string   `json:"contentType" yaml:"contentType"`
+	Sources     []string `json:"sources" yaml:"sources"`
+
+	// RunAtStartup runs the job once when the worker starts, outside
+	// CATCHUP_WINDOW.
+	RunAtStartup bool `json:"runAtStartup" yaml:"runAtStartup"`
+}
+
+// ParseJobs validates the config file's jobs, parsing each schedule and
+// template up front.
+func ParseJobs(configs []JobConfig) ([]*Job, error) {
+	var problems []error
+	var jobs []*Job
+	names := map[string]bool{}
+	for i, c := range configs {
+		field := fmt.Sprintf("jobs[%d]", i)
+		j := &Job{Job: &generator.Job{Name: strings.TrimSpace(c.Name), Template: c.Template, Model: c.Model, Backend: c.Backend, ContentType: strings.TrimSpace(c.ContentType)}, Schedule: c.Schedule, Timezone: c.Timezone, RunAtStartup: c.RunAtStartup}
+
+		switch {
+		case j.Name == "":
+			problems = append(problems, fmt.Errorf("%s.name: must not be empty", field))
+		case names[j.Name]:
+			problems = append(problems, fmt.Errorf("%s.name: %q is used by another job", field, j.Name))
+		}
+		names[j.Name] = true
+
+		if j.Timezone != "" {
+			if _, err := time.LoadLocation(j.Timezone); err != nil {
+				problems = append(problems, fmt.Errorf("%s.timezone: %v", field, err))
+			}
+		}
+		if j.Schedule == "" {
+			problems = append(problems, fmt.Errorf("%s.schedule: must not be empty", field))
+		} else if s, err := ParseSchedule(j.Schedule, j.Timezone); err != nil {
+			problems = append(problems, fmt.Errorf("%s.schedule: %v", field, err))
+		} else {
+			j.schedule = s
+		}
+
+		if c.Outputs != nil {
+			o, err := output.ParseOutputs(strings.Join(c.Outputs, ","))
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.outputs: %v", field, err))
+			} else if len(o) == 0 {
+				problems = append(problems, fmt.Errorf("%s.outputs: must list at least one output", field))
+			}
+			j.Outputs = o
+		}
+		if c.Locales != nil {
+			l, err := generator.ParseLocales(strings.Join(c.Locales, ","))
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.locales: %v", field, err))
+			}
+			j.Locales = append([]string{}, l...)
+		}
+		if c.Publishers != nil {
+			p, err := output.ParsePublishers(strings.Join(c.Publishers, ","))
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.publishers: %v", field, err))
+			}
+			j.Publishers = append([]string{}, p...)
+		}
+
+		if c.Sources != nil {
+			urls, err := sources.ParseURLs(strings.Join(c.Sources, ","))
+			if err != nil {
+				problems = append(problems, fmt.Errorf("%s.sources: %v", field, err))
+			}
+			j.Sources = append([]string{}, urls...)
+		}
+
+		if err := j.LoadTemplate(); err != nil {
+			problems = append(problems, fmt.Errorf("%s.template: %v", field, err))
+		}
+
+		jobs = append(jobs, j)
+	}
+	return jobs, generator.JoinProblems(problems)
+}
+
+// SetupJobs builds each job's provider and sinks once the global
+// configuration is loaded, resolves their content types and moves jobs
+// without a timezone to CRON_TZ.
+func SetupJobs() error {
+	var problems []error
+	for _, j := range Jobs {
+		j.Setup()
+		if err := j.LoadContentType(); err != nil {
+			problems = append(problems, fmt.Errorf("job %q: contentType: %v", j.Name, err))
+		}
+		if j.Timezone == "" && Timezone != "" {
+			if s, err := ParseSchedule(j.Schedule, Timezone); err == nil {
+				j.schedule = s
+			}
+		}
+	}
+	return generator.JoinProblems(problems)
+}
+
+// ScheduledJobs returns the configured jobs, or the default job described
+// by CRON_SCHEDULE.
+func ScheduledJobs() []*Job {
+	if len(Jobs) > 0 {
+		return Jobs
+	}
+	defaultJobOnce.Do(func() {
+		defaultJob = &Job{Job: &generator.Job{}, Schedule: CronSchedule, RunAtStartup: true, schedule: DefaultSchedule}
+	})
+	return []*Job{defaultJob}
+}
+
+var (
+	defaultJobOnce sync.Once
+	defaultJob     *Job
+)
+
+// FindJob returns the configured job called name, or nil.
+func FindJob(name string) *Job {
+	for _, j := range Jobs {
+		if j.Name == name {
+			return j
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"promptcraft-groq/pkg/generator"
+)
+
+// RunRecord is the outcome of one run, as listed by /status.
+type RunRecord struct {
+	ID        string    `json:"id,omitempty"`
+	Job       string    `json:"job,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	Sector    string    `json:"sector,omitempty"`
+	Status    string    `json:"status"`
+	Stage     string    `json:"stage,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMs int64     `json:"latencyMs"`
+}
+
+// RunRing keeps the last N run records, overwriting the oldest once full.
+type RunRing struct {
+	mu      sync.Mutex
+	records []RunRecord
+	next    int
+	full    bool
+}
+
+// NewRunRing returns a ring that keeps the last size records.
+func NewRunRing(size int) *RunRing {
+	if size < 1 {
+		size = 1
+	}
+	return &RunRing{records: make([]RunRecord, size)}
+}
+
+func (r *RunRing) Add(rec RunRecord) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Snapshot returns the stored records, newest first.
+func (r *RunRing) Snapshot() []RunRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := r.next
+	if r.full {
+		n = len(r.records)
+	}
+	out := make([]RunRecord, 0, n)
+	for i := 1; i <= n; i++ {
+		idx := (r.next - i + len(r.records)) % len(r.records)
+		out = append(out, r.records[idx])
+	}
+	return out
+}
+
+var RecentRuns = NewRunRing(20)
+
+var (
+	lastRunsMu sync.Mutex
+	lastRuns   = map[string]RunRecord{}
+)
+
+func recordLastRun(rec RunRecord) {
+	lastRunsMu.Lock()
+	lastRuns[rec.Job] = rec
+	lastRunsMu.Unlock()
+}
+
+// lastRun returns the job's most recent run, if it has had one.
+func lastRun(job string) (RunRecord, bool) {
+	lastRunsMu.Lock()
+	defer lastRunsMu.Unlock()
+	rec, ok := lastRuns[job]
+	return rec, ok
+}
+
+type failureInfo struct {
+	RunID     string    `json:"runId"`
+	Stage     string    `json:"stage"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+var (
+	lastFailureMu sync.Mutex
+	lastFailure   *failureInfo
+)
+
+func recordFailure(rec RunRecord) {
+	lastFailureMu.Lock()
+	defer lastFailureMu.Unlock()
+	lastFailure = &failureInfo{RunID: rec.ID, Stage: rec.Stage, Error: rec.Error, Timestamp: rec.Timestamp}
+}
+
+var (
+	HTTPGenerateConcurrency = 1
+	generateSlots           chan struct{}
+
+	// GenerateRetryAfter is the Retry-After sent while /generate is busy,
+	// about as long as one model request may take.
+	GenerateRetryAfter = 20 * time.Second
+)
+
+// tooManyGenerations answers 429 with GenerateRetryAfter, for /generate
+// and /jobs/{name}/run, which share the generation slots.
+func tooManyGenerations(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(GenerateRetryAfter.Seconds())))
+	http.Error(w, "too many generations in flight", http.StatusTooManyRequests)
+}
+
+// generateHandler runs one generation on demand, answering 429 when
+// HTTP_GENERATE_CONCURRENCY generations are already in flight.
+func generateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+
+	select {
+	case generateSlots <- struct{}{}:
+		defer func() { <-generateSlots }()
+	default:
+		tooManyGenerations(w)
+		return
+	}
+
+	ctx := r.Context()
+	if name := r.URL.Query().Get("job"); name != "" {
+		j := FindJob(name)
+		if j == nil {
+			http.Error(w, "unknown job", http.StatusNotFound)
+			return
+		}
+		ctx = generator.WithJob(ctx, j.Job)
+	}
+
+	log.Println("⚡ On-demand prompt generation started...")
+	rec, _ := RunOnce(ctx)
+	w.Header().Set("Content-Type", "application/json")
+	if rec.Status != "success" {
+		w.WriteHeader(http.StatusBadGateway)
+	}
+	json.NewEncoder(w).Encode(rec)
+}
+
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	lastFailureMu.Lock()
+	failure := lastFailure
+	lastFailureMu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"recentRuns":  RecentRuns.Snapshot(),
+		"lastFailure": failure,
+	})
+}
+
+// runLimit closes Done once max scheduled runs have completed. A max of
+// zero means unlimited.
+type runLimit struct {
+	mu    sync.Mutex
+	max   int
+	count int
+	done  chan struct{}
+}
+
+func newRunLimit(max int) *runLimit {
+	return &runLimit{max: max, done: make(chan struct{})}
+}
+
+func (l *runLimit) Record() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.count++
+	if l.max > 0 && l.count == l.max {
+		log.Printf("🏁 Reached MAX_RUNS=%d", l.max)
+		close(l.done)
+	}
+}
+
+func (l *runLimit) Reached() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.max > 0 && l.count >= l.max
+}
+
+func (l *runLimit) Done() <-chan struct{} { return l.done }
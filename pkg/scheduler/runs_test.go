@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunRing(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		adds []string
+		want []string
+	}{
+		{name: "empty", size: 3, want: []string{}},
+		{name: "partly filled", size: 3, adds: []string{"a", "b"}, want: []string{"b", "a"}},
+		{name: "exactly full", size: 3, adds: []string{"a", "b", "c"}, want: []string{"c", "b", "a"}},
+		{name: "wrapped", size: 3, adds: []string{"a", "b", "c", "d", "e"}, want: []string{"e", "d", "c"}},
+		{name: "size below one keeps one", size: 0, adds: []string{"a", "b"}, want: []string{"b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRunRing(tt.size)
+			for _, id := range tt.adds {
+				r.Add(RunRecord{ID: id})
+			}
+			got := []string{}
+			for _, rec := range r.Snapshot() {
+				got = append(got, rec.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Snapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunLimit(t *testing.T) {
+	tests := []struct {
+		name        string
+		max         int
+		runs        int
+		wantReached bool
+	}{
+		{name: "unlimited", max: 0, runs: 5, wantReached: false},
+		{name: "below the limit", max: 3, runs: 2, wantReached: false},
+		{name: "at the limit", max: 3, runs: 3, wantReached: true},
+		{name: "past the limit", max: 3, runs: 4, wantReached: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newRunLimit(tt.max)
+			for i := 0; i < tt.runs; i++ {
+				l.Record()
+			}
+			if got := l.Reached(); got != tt.wantReached {
+				t.Errorf("Reached() = %v, want %v", got, tt.wantReached)
+			}
+			select {
+			case <-l.Done():
+				if !tt.wantReached {
+					t.Error("Done() closed before the limit")
+				}
+			default:
+				if tt.wantReached {
+					t.Error("Done() still open at the limit")
+				}
+			}
+		})
+	}
+}
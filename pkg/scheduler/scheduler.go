@@ -0,0 +1,353 @@
+// Package scheduler runs the jobs in batches, on their cron schedules and
+// on demand, and records every run.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"promptcraft-groq/internal/runctx"
+	"promptcraft-groq/internal/state"
+	"promptcraft-groq/pkg/approval"
+	"promptcraft-groq/pkg/generator"
+	"promptcraft-groq/pkg/output"
+)
+
+// RunBatch runs one batch for the context's job and returns how
+// many of its prompts failed.
+func RunBatch(ctx context.Context) int {
+	count := PromptsPerRun
+	if count > MaxPerRun {
+		runctx.Logf(ctx, "⚠️ PROMPTS_PER_RUN=%d exceeds MAX_PER_RUN=%d, clamping", count, MaxPerRun)
+		count = MaxPerRun
+	}
+
+	j := generator.JobFrom(ctx)
+	job := runctx.JobName(ctx)
+	if job != "" {
+		runctx.Logf(ctx, "🗂️ Job %q: generating %d prompt(s)", job, count)
+	}
+
+	locales := generator.LocalesFrom(ctx)
+	if len(locales) == 1 {
+		ctx = generator.WithLocale(ctx, locales[0])
+	}
+
+	start := time.Now()
+	runs, succeeded := count, 0
+	// Bulk sends go to BACKEND_BULK_URL only, so a job with its own
+	// backend, other outputs, publishers or several locales sends its
+	// prompts one by one.
+	if BackendBulk && !generator.DryRun && !approval.Enabled && generator.SchemaFrom(ctx) == nil && (j == nil || j.Backend == "") && output.BackendOnly(generator.SinksFrom(ctx)) && len(locales) <= 1 {
+		succeeded = runBulk(ctx, count)
+	} else {
+		var mu sync.Mutex
+		runs = 0
+		forEachConcurrent(ctx, count, MaxConcurrency, func() {
+			recs := runLocalized(ctx)
+			mu.Lock()
+			runs += len(recs)
+			succeeded += countSucceeded(recs)
+			mu.Unlock()
+		})
+	}
+
+	if succeeded > 0 {
+		LastSuccess.Set(jobLabel(job), start)
+	}
+
+	switch {
+	case job != "":
+		runctx.Logf(ctx, "📊 Job %q finished: %d succeeded, %d failed", job, succeeded, runs-succeeded)
+	case runs > 1:
+		runctx.Logf(ctx, "📊 Run finished: %d succeeded, %d failed", succeeded, runs-succeeded)
+	}
+	return runs - succeeded
+}
+
+// forEachConcurrent calls fn count times with at most concurrency calls
+// in flight, starting no new calls once ctx is done or a shutdown has
+// begun. With a single call or a concurrency of 1 everything runs in order
+// on the caller's goroutine.
+func forEachConcurrent(ctx context.Context, count, concurrency int, fn func()) {
+	if count == 1 || concurrency == 1 {
+		for i := 0; i < count && ctx.Err() == nil && !ShuttingDown(); i++ {
+			fn()
+		}
+		return
+	}
+
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < count && ctx.Err() == nil && !ShuttingDown(); i++ {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// RunOnce generates and sends a single prompt under a fresh run ID and
+// records the outcome in RecentRuns.
+func RunOnce(ctx context.Context) (RunRecord, error) {
+	rec, _, err := runRecorded(ctx, generator.GenerateAndSend)
+	return rec, err
+}
+
+// runRecorded runs fn under a new run ID and records the run.
+func runRecorded(ctx context.Context, fn func(context.Context) (generator.PromptResponse, error)) (RunRecord, generator.PromptResponse, error) {
+	start := time.Now()
+	runID := runctx.NewRunID()
+	p, err := fn(runctx.WithRunID(ctx, runID))
+	rec := finishRun(ctx, runID, start, p, err)
+	return rec, p, err
+}
+
+// finishRun builds the run's record and adds it to RecentRuns.
+func finishRun(ctx context.Context, runID string, start time.Time, p generator.PromptResponse, err error) RunRecord {
+	rec := RunRecord{
+		ID:        runID,
+		Job:       runctx.JobName(ctx),
+		Timestamp: start,
+		Sector:    p.Sector,
+		Status:    "success",
+		LatencyMs: time.Since(start).Milliseconds(),
+	}
+	switch {
+	case err != nil && !generator.Delivered(err):
+		noteInterrupted(err)
+		rec.Status = "failed"
+		rec.Stage = generator.FailureStage(err)
+		rec.Error = err.Error()
+		recordFailure(rec)
+	case err != nil:
+		// Sent, but secondary outputs still need the payload.
+		rec.Error = err.Error()
+	}
+	RecentRuns.Add(rec)
+	recordLastRun(rec)
+	runMetrics.Record(rec)
+	History.Record(rec, p, err)
+	return rec
+}
+
+// RunJobsOnce runs every scheduled job a single time and returns how many
+// prompts failed.
+func RunJobsOnce(ctx context.Context) int {
+	failed := 0
+	for _, j := range ScheduledJobs() {
+		if ShuttingDown() {
+			break
+		}
+		failed += RunBatch(generator.WithJob(ctx, j.Job))
+	}
+	return failed
+}
+
+var (
+	PromptsPerRun = 1
+	MaxPerRun     = 50
+
+	// MaxConcurrency bounds how many prompts of a batch are generated at
+	// once.
+	MaxConcurrency = 3
+
+	MaxRuns int
+
+	// StartupRunDelay holds back the startup run only; the HTTP server is
+	// already listening while it elapses.
+	StartupRunDelay time.Duration
+)
+
+// runLocalized makes one run per locale of the job and returns their
+// records. With LOCALE_MODE=translate only the first locale is generated;
+// the others are translations of it, made as long as the generated prompt
+// exists, even if sending it failed.
+func runLocalized(ctx context.Context) []RunRecord {
+	locales := generator.LocalesFrom(ctx)
+	if len(locales) <= 1 {
+		rec, _ := RunOnce(ctx)
+		return []RunRecord{rec}
+	}
+
+	var recs []RunRecord
+	var primary generator.PromptResponse
+	for i, locale := range locales {
+		if ctx.Err() != nil || ShuttingDown() {
+			break
+		}
+		fn := generator.GenerateAndSend
+		if i > 0 && generator.LocaleMode == "translate" {
+			fn = func(ctx context.Context) (generator.PromptResponse, error) {
+				return generator.TranslateAndSend(ctx, primary)
+			}
+		}
+		rec, p, err := runRecorded(generator.WithLocale(ctx, locale), fn)
+		recs = append(recs, rec)
+		if i == 0 && generator.LocaleMode == "translate" {
+			if err != nil && generator.FailureStage(err) != "backend" {
+				break
+			}
+			primary = p
+		}
+	}
+	return recs
+}
+
+func countSucceeded(recs []RunRecord) int {
+	n := 0
+	for _, rec := range recs {
+		if rec.Status == "success" {
+			n++
+		}
+	}
+	return n
+}
+
+// Run starts the daemon: the HTTP server, a startup run of every job, the
+// cron schedule and the replay worker. It returns once MAX_RUNS is
+// reached or a shutdown has finished, with an error if the shutdown
+// cancelled any runs.
+func Run() error {
+	log.Println("✅ Starting production cron job...")
+	state.StartFlusher()
+
+	runCtx, cancelRuns := context.WithCancel(context.Background())
+	defer cancelRuns()
+	WatchSignals(cancelRuns)
+
+	generateSlots = make(chan struct{}, HTTPGenerateConcurrency)
+	// The server starts before the startup run so health checks and the
+	// port check pass while it is still in progress; /readyz reports the
+	// scheduler as not started until the schedule is running.
+	server := startServer(runCtx)
+
+	limit := newRunLimit(MaxRuns)
+	if StartupRunDelay > 0 {
+		log.Printf("⏱️ Waiting %s before the startup run", StartupRunDelay)
+		select {
+		case <-time.After(StartupRunDelay):
+		case <-shutdownStarted:
+		}
+	}
+	jobs := ScheduledJobs()
+	for _, j := range startupJobs(jobs) {
+		if ShuttingDown() {
+			break
+		}
+		RunBatch(generator.WithJob(runCtx, j.Job))
+		limit.Record()
+	}
+
+	// Runs daily at 9 AM unless CRON_SCHEDULE or the config file's jobs say
+	// otherwise
+	c := cron.New()
+	for _, j := range jobs {
+		j := j
+		c.Schedule(j.schedule, cron.FuncJob(func() {
+			if limit.Reached() || ShuttingDown() {
+				return
+			}
+			if j.Paused() {
+				log.Printf("⏸️ Job %q is paused, skipping its scheduled run", j.Label())
+				return
+			}
+			if j.Name == "" {
+				log.Println("⏳ Scheduled prompt generation started...")
+			} else {
+				log.Printf("⏳ Scheduled job %q started...", j.Name)
+			}
+			RunBatch(generator.WithJob(runCtx, j.Job))
+			limit.Record()
+		}))
+		if j.Name == "" {
+			log.Printf("📅 Schedule %q, next run at %s", j.Schedule, j.Next(time.Now()).Format(time.RFC3339))
+		} else {
+			log.Printf("📅 Job %q on schedule %q, next run at %s", j.Name, j.Schedule, j.Next(time.Now()).Format(time.RFC3339))
+		}
+	}
+	c.Start()
+	schedulerReady.Store(true)
+	startReplayWorker(runCtx)
+	startApprovalSweeper()
+
+	// Keep alive until a shutdown signal, or MAX_RUNS if set
+	select {
+	case <-limit.Done():
+	case <-shutdownStarted:
+	}
+
+	// Both waits are bounded: WatchSignals cancels whatever is still
+	// running once ShutdownTimeout has passed.
+	<-c.Stop().Done()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout+5*time.Second)
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("⚠️ HTTP server shutdown:", err)
+	}
+	cancel()
+	state.FlushAll()
+
+	if n := interruptedRuns.Load(); n > 0 {
+		return fmt.Errorf("%d run(s) were cancelled by the shutdown", n)
+	}
+	log.Println("👋 All runs finished, exiting")
+	return nil
+
+}
+
+// startServer serves the status, health, metrics, admin and approval
+// endpoints on HEALTH_PORT in the background.
+func startServer(runCtx context.Context) *http.Server {
+	// === 🔊 HTTP server for Render Web Service and health checks ===
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("✅ Autopost worker is running.\n"))
+	})
+	mux.HandleFunc("/status", statusHandler)
+	if AdminToken != "" {
+		mux.HandleFunc("/generate", adminOnly(generateHandler))
+	} else {
+		// Legacy: without ADMIN_TOKEN /generate stays open to anyone who can
+		// reach HEALTH_PORT.
+		mux.HandleFunc("/generate", generateHandler)
+		log.Println("⚠️ /generate is unauthenticated; set ADMIN_TOKEN to protect it")
+	}
+	mux.HandleFunc("/healthz", healthzHandler)
+	mux.HandleFunc("/readyz", readyzHandler)
+	mux.HandleFunc("/metrics", metricsHandler)
+	if AdminToken != "" {
+		mux.HandleFunc("/jobs", adminOnly(jobsHandler))
+		mux.HandleFunc("/jobs/", adminOnly(jobHandler))
+		mux.HandleFunc("/runs/latest", adminOnly(latestRunHandler))
+		log.Println("🛠️ Admin API enabled on /generate, /jobs and /runs/latest")
+	}
+	if approval.Enabled {
+		if AdminToken != "" {
+			mux.HandleFunc("/approvals", adminOnly(approvalsHandler))
+		}
+		mux.HandleFunc("/approvals/", approvalHandler)
+		log.Printf("📝 Approval mode: payloads wait for review (%s), expiring after %s", output.ReviewChannel, approval.Expire)
+	}
+	// On-demand runs share runCtx, so the shutdown timeout cancels them too.
+	server := &http.Server{
+		Addr:        ":" + HealthPort,
+		Handler:     mux,
+		BaseContext: func(net.Listener) context.Context { return runCtx },
+	}
+	go func() {
+		log.Println("🌐 HTTP server listening on", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("❌ HTTP Server error:", err)
+		}
+	}()
+	return server
+}
@@ -0,0 +1,66 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ShutdownTimeout is how long running jobs get to finish after SIGINT or
+// SIGTERM before they are cancelled.
+var ShutdownTimeout = 20 * time.Second
+
+var (
+	shutdownStarted = make(chan struct{})
+	// interruptedRuns counts runs cancelled by the shutdown, which makes
+	// the process exit non-zero.
+	interruptedRuns atomic.Int32
+)
+
+// WatchSignals starts a drain on the first SIGINT/SIGTERM: no new runs
+// start, and running ones are cancelled once ShutdownTimeout has passed. A
+// second signal exits at once.
+func WatchSignals(cancelRuns context.CancelFunc) {
+	sig := make(chan os.Signal, 2)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		s := <-sig
+		log.Printf("🛑 Received %s, letting running jobs finish for up to %s (signal again to force)", s, ShutdownTimeout)
+		close(shutdownStarted)
+
+		select {
+		case <-time.After(ShutdownTimeout):
+			log.Printf("⏱️ Shutdown timeout of %s reached, cancelling running jobs", ShutdownTimeout)
+			cancelRuns()
+		case <-sig:
+			log.Println("💥 Second signal received, exiting immediately")
+			os.Exit(1)
+		}
+
+		<-sig
+		log.Println("💥 Second signal received, exiting immediately")
+		os.Exit(1)
+	}()
+}
+
+// ShuttingDown reports whether a shutdown has begun.
+func ShuttingDown() bool {
+	select {
+	case <-shutdownStarted:
+		return true
+	default:
+		return false
+	}
+}
+
+// noteInterrupted records a run that the shutdown cancelled.
+func noteInterrupted(err error) {
+	if errors.Is(err, context.Canceled) && ShuttingDown() {
+		interruptedRuns.Add(1)
+	}
+}
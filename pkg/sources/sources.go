@@ -0,0 +1,313 @@
+// Package sources fetches RSS and Atom feeds or web pages so generated
+// content can be grounded in recent news.
+package sources
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"promptcraft-groq/internal/runctx"
+)
+
+// Item is one headline taken from a source.
+type Item struct {
+	Title     string
+	Summary   string
+	Link      string
+	Source    string
+	Published time.Time
+}
+
+var (
+	// URLs are the sources of jobs that do not list their own.
+	URLs []string
+
+	// Timeout applies to each source separately.
+	Timeout = 10 * time.Second
+	// CacheTTL is how long a fetched source is reused. A source that
+	// fails to refresh keeps serving its last items.
+	CacheTTL = 30 * time.Minute
+	// MaxItems caps the headlines given to the model across all sources.
+	MaxItems = 8
+	// MaxAge drops items published longer ago; zero keeps them all.
+	MaxAge = 7 * 24 * time.Hour
+
+	maxSummaryRunes = 280
+	maxBodyBytes    = int64(5 << 20)
+)
+
+type cacheEntry struct {
+	items   []Item
+	fetched time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]cacheEntry{}
+)
+
+// ParseURLs splits a comma-separated SOURCES list, requiring absolute
+// http or https URLs.
+func ParseURLs(s string) ([]string, error) {
+	var urls []string
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		parsed, err := url.Parse(part)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("invalid source URL %q (want http or https)", part)
+		}
+		urls = append(urls, part)
+	}
+	return urls, nil
+}
+
+// Recent fetches every source, or takes it from the cache, and returns
+// their newest items. Sources that cannot be fetched are logged and
+// skipped.
+func Recent(ctx context.Context, urls []string) []Item {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		items []Item
+	)
+	for _, u := range urls {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			got, err := cached(ctx, u)
+			if err != nil {
+				runctx.Logf(ctx, "⚠️ Skipping source %s: %v", u, err)
+			}
+			mu.Lock()
+			items = append(items, got...)
+			mu.Unlock()
+		}(u)
+	}
+	wg.Wait()
+	return newest(items, time.Now())
+}
+
+// newest drops old and repeated headlines and returns up to MaxItems,
+// newest first. Items without a date sort last.
+func newest(items []Item, now time.Time) []Item {
+	seen := map[string]bool{}
+	var kept []Item
+	for _, it := range items {
+		key := strings.ToLower(it.Title)
+		if it.Title == "" || seen[key] {
+			continue
+		}
+		if MaxAge > 0 && !it.Published.IsZero() && now.Sub(it.Published) > MaxAge {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, it)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		return kept[i].Published.After(kept[j].Published)
+	})
+	if len(kept) > MaxItems {
+		kept = kept[:MaxItems]
+	}
+	return kept
+}
+
+func cached(ctx context.Context, u string) ([]Item, error) {
+	cacheMu.Lock()
+	entry, ok := cache[u]
+	cacheMu.Unlock()
+	if ok && time.Since(entry.fetched) < CacheTTL {
+		return entry.items, nil
+	}
+
+	items, err := fetch(ctx, u)
+	if err != nil {
+		if ok {
+			return entry.items, fmt.Errorf("%w (using items from %s)", err, entry.fetched.Format(time.RFC3339))
+		}
+		return nil, err
+	}
+	cacheMu.Lock()
+	cache[u] = cacheEntry{items: items, fetched: time.Now()}
+	cacheMu.Unlock()
+	return items, nil
+}
+
+func fetch(ctx context.Context, u string) ([]Item, error) {
+	ctx, cancel := runctx.WithStageTimeout(ctx, Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "autopost (+source ingestion)")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/xml, text/xml, text/html;q=0.8")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch failed (%s)", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if strings.Contains(contentType, "html") && !strings.Contains(contentType, "xhtml") {
+		return parsePage(u, body)
+	}
+	items, err := parseFeed(u, body)
+	if err != nil && strings.Contains(strings.ToLower(string(body[:min(len(body), 512)])), "<html") {
+		return parsePage(u, body)
+	}
+	return items, err
+}
+
+// feed covers RSS 2.0 (channel/item) and Atom (entry) in one shape.
+type feed struct {
+	XMLName xml.Name
+	Title   string `xml:"title"`
+	Channel struct {
+		Title string     `xml:"title"`
+		Items []feedItem `xml:"item"`
+	} `xml:"channel"`
+	Entries []feedItem `xml:"entry"`
+}
+
+type feedItem struct {
+	Title       string `xml:"title"`
+	Description string `xml:"description"`
+	Summary     string `xml:"summary"`
+	Content     string `xml:"content"`
+	PubDate     string `xml:"pubDate"`
+	Published   string `xml:"published"`
+	Updated     string `xml:"updated"`
+	Links       []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Text string `xml:",chardata"`
+	} `xml:"link"`
+}
+
+func parseFeed(u string, body []byte) ([]Item, error) {
+	var f feed
+	if err := xml.Unmarshal(body, &f); err != nil {
+		return nil, fmt.Errorf("not an RSS or Atom feed: %w", err)
+	}
+	source, entries := f.Channel.Title, f.Channel.Items
+	if strings.EqualFold(f.XMLName.Local, "feed") {
+		source, entries = f.Title, f.Entries
+	}
+	if source == "" {
+		source = u
+	}
+
+	var items []Item
+	for _, e := range entries {
+		summary := e.Description
+		if summary == "" {
+			summary = e.Summary
+		}
+		if summary == "" {
+			summary = e.Content
+		}
+		it := Item{
+			Title:     cleanText(e.Title, 0),
+			Summary:   cleanText(summary, maxSummaryRunes),
+			Source:    cleanText(source, 0),
+			Published: parseDate(firstNonEmpty(e.PubDate, e.Published, e.Updated)),
+		}
+		for _, l := range e.Links {
+			if l.Href != "" && (l.Rel == "" || l.Rel == "alternate") {
+				it.Link = l.Href
+				break
+			}
+			if strings.TrimSpace(l.Text) != "" {
+				it.Link = strings.TrimSpace(l.Text)
+				break
+			}
+		}
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+var (
+	pageTitleRe = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	metaRe      = regexp.MustCompile(`(?is)<meta\s+[^>]*>`)
+	metaAttrRe  = regexp.MustCompile(`(?is)(name|property|content)\s*=\s*("[^"]*"|'[^']*')`)
+)
+
+// parsePage turns a web page into a single item from its title and meta
+// description.
+func parsePage(u string, body []byte) ([]Item, error) {
+	m := pageTitleRe.FindSubmatch(body)
+	if m == nil {
+		return nil, fmt.Errorf("page has no title")
+	}
+	it := Item{Title: cleanText(string(m[1]), 0), Link: u, Source: u}
+	for _, tag := range metaRe.FindAll(body, -1) {
+		attrs := map[string]string{}
+		for _, a := range metaAttrRe.FindAllSubmatch(tag, -1) {
+			attrs[strings.ToLower(string(a[1]))] = strings.Trim(string(a[2]), `"'`)
+		}
+		name := strings.ToLower(attrs["name"] + attrs["property"])
+		if name == "description" || name == "og:description" {
+			it.Summary = cleanText(attrs["content"], maxSummaryRunes)
+			break
+		}
+	}
+	return []Item{it}, nil
+}
+
+var tagRe = regexp.MustCompile(`(?s)<[^>]*>`)
+
+// cleanText strips markup and entities, collapses whitespace and cuts the
+// text to limit runes when limit is positive.
+func cleanText(s string, limit int) string {
+	s = html.UnescapeString(tagRe.ReplaceAllString(html.UnescapeString(s), " "))
+	s = strings.Join(strings.Fields(s), " ")
+	if limit > 0 && utf8.RuneCountInString(s) > limit {
+		s = string([]rune(s)[:limit-1]) + "…"
+	}
+	return s
+}
+
+var dateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST", "2006-01-02"}
+
+func parseDate(s string) time.Time {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}